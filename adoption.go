@@ -0,0 +1,140 @@
+package promise
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CycleError is the rejection reason of a promise that would end up waiting on itself, by adopting itself or a promise
+// that in turn waits on it. It is wrapped in a *ProgrammerError.
+type CycleError struct {
+	cycle []Promise
+}
+
+// Cycle returns the promises forming the cycle, starting and ending with the promise that was rejected.
+func (e *CycleError) Cycle() []Promise {
+	return append([]Promise(nil), e.cycle...)
+}
+
+func (e *CycleError) Error() string {
+	return "promise adoption cycle: " + formatChain(e.cycle, false)
+}
+
+// Serializes adoption, so that two promises adopting each other at the same time cannot both miss the cycle.
+var adoptionLock sync.Mutex
+
+var adoptionDebug struct {
+	sync.Mutex
+	threshold time.Duration
+	report    func(waiting []Promise)
+}
+
+// DebugAdoption turns on stall detection: when a promise still waits for the promise it adopted after threshold, report
+// is called with the chain of promises it is waiting on, ending with the one that holds everything up. A nil report
+// logs the chain, a zero threshold turns the detection off.
+func DebugAdoption(threshold time.Duration, report func(waiting []Promise)) {
+	if report == nil {
+		report = func(waiting []Promise) {
+			log.Printf("promise pending for more than %s: %s", threshold, formatChain(waiting, true))
+		}
+	}
+
+	adoptionDebug.Lock()
+	defer adoptionDebug.Unlock()
+
+	adoptionDebug.threshold, adoptionDebug.report = threshold, report
+}
+
+// The promise this one is waiting on is kept under its own lock, since walking the chain must not wait for listeners
+// that are being run under promiseStateLock. It is cleared when the promise settles.
+func (this *PromiseProto) setAwaiting(promise Promise) {
+	this.awaitingLock.Lock()
+	defer this.awaitingLock.Unlock()
+
+	this.awaiting = promise
+}
+
+// Completes this promise the way thenable settles, unless that would make it wait on itself.
+func (this *PromiseProto) adopt(thenable Thenable, complete func(state callbackState, results []reflect.Value, err error) bool) {
+	promise, isPromise := thenable.(Promise)
+	if !isPromise {
+		this.adoptForeign(thenable, complete)
+		return
+	}
+
+	adoptionLock.Lock()
+	if cycle := findCycle(this, promise); cycle != nil {
+		adoptionLock.Unlock()
+		complete(STATE_REJECTED, nil, Programmer(&CycleError{cycle}))
+		return
+	}
+	this.setAwaiting(promise)
+	adoptionLock.Unlock()
+
+	adoptionDebug.Lock()
+	threshold, report := adoptionDebug.threshold, adoptionDebug.report
+	adoptionDebug.Unlock()
+	if threshold > 0 {
+		currentClock().AfterFunc(threshold, func() {
+			if this.State() == STATE_PENDING {
+				report(awaitingChain(this))
+			}
+		})
+	}
+
+	promise.OnProgress(this.notifyProgress)
+	proto, isProto := promise.(*PromiseProto)
+	if !isProto {
+		this.adoptForeign(promise, complete)
+		return
+	}
+	proto.addStateCompleteListener(func(state callbackState) {
+		complete(state, proto.results(), proto.Error())
+	})
+}
+
+func findCycle(promise *PromiseProto, adopted Promise) []Promise {
+	chain := awaitingChain(adopted)
+	for i, waiting := range chain {
+		if waiting == Promise(promise) {
+			return append([]Promise{promise}, chain[:i+1]...)
+		}
+	}
+	return nil
+}
+
+// Follows what the pending promises starting from promise are waiting on, be it the promise they were derived from or
+// the one they adopted.
+func awaitingChain(promise Promise) (chain []Promise) {
+	visited := make(map[Promise]bool)
+	for promise != nil && !visited[promise] {
+		visited[promise] = true
+		chain = append(chain, promise)
+
+		proto, isProto := promise.(*PromiseProto)
+		if !isProto { break }
+
+		proto.awaitingLock.Lock()
+		promise = proto.awaiting
+		proto.awaitingLock.Unlock()
+	}
+	return
+}
+
+func formatChain(chain []Promise, withState bool) string {
+	labels := make([]string, len(chain))
+	for i, promise := range chain {
+		if labels[i] = promise.Label(); labels[i] == "" {
+			labels[i] = fmt.Sprintf("%p", promise)
+		}
+		labels[i] = fmt.Sprintf("%q", labels[i])
+		if withState {
+			labels[i] += fmt.Sprintf(" (%s)", promise.State())
+		}
+	}
+	return strings.Join(labels, " -> ")
+}
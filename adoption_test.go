@@ -0,0 +1,76 @@
+package promise_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAdoptSelfRejectsWithCycleError(t *testing.T) {
+	// Prepare
+	ready := make(chan struct{})
+	// Test
+	var p promise.Promise
+	p = promise.Resolve(1).Then(func(value int) promise.Promise {
+		<-ready
+		return p
+	})
+	close(ready)
+	// Verify
+	if promisetest.AssertRejected(t, p, settleTimeout, reflect.TypeOf(&promise.CycleError{})) {
+		var cycleError *promise.CycleError
+		errors.As(p.Error(), &cycleError)
+		assert.Equal(t, []promise.Promise{p, p}, cycleError.Cycle())
+	}
+}
+
+func TestAdoptCycleRejectsWithCycleError(t *testing.T) {
+	// Prepare
+	ready := make(chan struct{})
+	// Test
+	var derived promise.Promise
+	adopting := promise.Resolve(1).Then(func(value int) promise.Promise {
+		<-ready
+		return derived
+	}).Named("adopting")
+	derived = adopting.Then(func(values ...interface{}) {}).Named("derived")
+	close(ready)
+	// Verify
+	if promisetest.AssertRejected(t, adopting, settleTimeout, promise.IsProgrammerError) {
+		var cycleError *promise.CycleError
+		if assert.True(t, errors.As(adopting.Error(), &cycleError)) {
+			assert.Equal(t, []promise.Promise{adopting, derived, adopting}, cycleError.Cycle())
+			assert.Contains(t, adopting.Error().Error(), `"adopting" -> "derived" -> "adopting"`)
+		}
+	}
+}
+
+func TestDebugAdoptionReportsStalls(t *testing.T) {
+	// Prepare
+	release := make(chan struct{})
+	defer close(release)
+	stalled := gated(release, func(resolve func(...interface{}), reject func(error)) {
+		resolve()
+	}).Named("stalled")
+	reported := make(chan []promise.Promise, 1)
+	clock := promise.NewFakeClock(time.Now())
+	defer promise.SetClock(clock)()
+	promise.DebugAdoption(time.Minute, func(waiting []promise.Promise) {
+		reported <- waiting
+	})
+	defer promise.DebugAdoption(0, nil)
+	// Test
+	adopting := promise.Resolve(1).Then(func(value int) promise.Promise {
+		return stalled
+	}).Named("adopting")
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	// Verify
+	if promisetest.Eventually(t, func() bool { return len(reported) == 1 }, settleTimeout) {
+		assert.Equal(t, []promise.Promise{adopting, stalled}, <-reported)
+	}
+}
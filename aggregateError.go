@@ -0,0 +1,56 @@
+package promise
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AggregateError is the rejection reason of combinators that collect failures, like Any and Some. The errors are kept
+// in the order of the input promises. When there are fewer promises than have to fulfill, it holds no errors and its
+// message tells how many were needed.
+type AggregateError struct {
+	errors []error
+	reason string
+}
+
+func newAggregateError(errs []error) *AggregateError {
+	aggregate := &AggregateError{}
+	for _, err := range errs {
+		if err != nil {
+			aggregate.errors = append(aggregate.errors, err)
+		}
+	}
+	return aggregate
+}
+
+func newShortfallError(count, total int) *AggregateError {
+	return &AggregateError{reason: fmt.Sprintf("need %d of %d promises to fulfill", count, total)}
+}
+
+func (e *AggregateError) Errors() []error {
+	return append([]error(nil), e.errors...)
+}
+
+func (e *AggregateError) Unwrap() []error {
+	return e.Errors()
+}
+
+func (e *AggregateError) Error() string {
+	if e.reason != "" {
+		return "aggregate error: " + e.reason
+	}
+
+	messages := make([]string, len(e.errors))
+	for i, err := range e.errors {
+		messages[i] = err.Error()
+	}
+
+	switch len(e.errors) {
+	case 0:
+		return "aggregate error: no promises were fulfilled"
+	case 1:
+		return "aggregate error: 1 promise was rejected: " + messages[0]
+	default:
+		return fmt.Sprintf("aggregate error: %d promises were rejected: %s", len(e.errors), strings.Join(messages, "; "))
+	}
+}
@@ -0,0 +1,393 @@
+package promise
+
+// Ports of the Promises/A+ compliance tests (https://github.com/promises-aplus/promises-tests), named after the sections
+// of the specification they cover. Not ported are the clauses that have no counterpart in Go: 2.2.5, handlers being
+// called without a this value, and 2.3.3.1 and 2.3.3.2, then being retrieved once and a throwing getter of then. A value
+// whose then is not a function, 2.3.3.4, is in Go a value that does not implement Thenable, covered by 2.3.4.
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func awaitAPlus(t *testing.T, done chan interface{}) interface{} {
+	select {
+	case value := <- done:
+		return value
+	case <-time.After(500 * time.Millisecond):
+		t.Error("timed out")
+		return nil
+	}
+}
+
+func settledAPlus(promise Promise) Promise {
+	settled := make(chan struct{})
+	promise.(*PromiseProto).addStateCompleteListener(func(state callbackState) {
+		close(settled)
+	})
+	<-settled
+	return promise
+}
+
+func TestAPlus_2_1_2_FulfilledPromiseCannotTransition(t *testing.T) {
+	// Prepare
+	done := make(chan interface{}, 2)
+	// Test
+	promise := NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		resolve("fulfilled")
+		reject(errors.New("rejected"))
+		resolve("fulfilled again")
+	})
+	promise.Then(func(value string) {
+		done <- value
+	}, func(err error) {
+		done <- err
+	})
+	// Verify
+	assert.Equal(t, "fulfilled", awaitAPlus(t, done))
+	assert.Equal(t, STATE_FULFILLED, promise.State())
+	assert.Equal(t, []interface{}{"fulfilled"}, promise.Results())
+}
+
+func TestAPlus_2_1_3_RejectedPromiseCannotTransition(t *testing.T) {
+	// Prepare
+	done := make(chan interface{}, 2)
+	rejection := errors.New("rejected")
+	// Test
+	promise := NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		reject(rejection)
+		resolve("fulfilled")
+	})
+	promise.Then(func(value string) {
+		done <- value
+	}, func(err error) {
+		done <- err
+	})
+	// Verify
+	assert.Equal(t, rejection, awaitAPlus(t, done))
+	assert.Equal(t, STATE_REJECTED, promise.State())
+	assert.Equal(t, rejection, promise.Error())
+}
+
+func TestAPlus_2_2_1_OptionalArguments(t *testing.T) {
+	// Prepare
+	done := make(chan interface{}, 2)
+	rejection := errors.New("rejected")
+	// Test
+	Resolve("fulfilled").Then(nil).Then(func(value string) {
+		done <- value
+	})
+	Reject(rejection).Then(func(value string) {
+		done <- value
+	}).Then(nil, func(err error) {
+		done <- err
+	})
+	// Verify
+	assert.ElementsMatch(t, []interface{}{"fulfilled", rejection}, []interface{}{awaitAPlus(t, done), awaitAPlus(t, done)})
+}
+
+func TestAPlus_2_2_2_OnFulfilledIsCalledOnce(t *testing.T) {
+	// Prepare
+	var calls int32
+	done := make(chan interface{}, 1)
+	// Test
+	derived := NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		resolve(1)
+		resolve(2)
+	}).Then(func(value int) {
+		atomic.AddInt32(&calls, 1)
+		done <- value
+	})
+	// Verify
+	assert.Equal(t, 1, awaitAPlus(t, done))
+	settledAPlus(derived)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestAPlus_2_2_3_OnRejectedIsCalledOnce(t *testing.T) {
+	// Prepare
+	var calls int32
+	done := make(chan interface{}, 1)
+	rejection := errors.New("rejected")
+	// Test
+	derived := NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		reject(rejection)
+		reject(errors.New("rejected again"))
+	}).Catch(func(err error) {
+		atomic.AddInt32(&calls, 1)
+		done <- err
+	})
+	// Verify
+	assert.Equal(t, rejection, awaitAPlus(t, done))
+	settledAPlus(derived)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestAPlus_2_2_4_HandlersRunAsynchronously(t *testing.T) {
+	// Prepare
+	release := make(chan struct{})
+	done := make(chan interface{}, 2)
+	returned := make(chan struct{}, 2)
+	settled := settledAPlus(Resolve("settled"))
+	// Test
+	go func() {
+		settled.Then(func(value string) {
+			<-release
+			done <- value
+		})
+		returned <- struct{}{}
+	}()
+	go func() {
+		NewPromise(func(resolve func(...interface{}), reject func(error)) {
+			resolve("resolved")
+			returned <- struct{}{}
+		}).Then(func(value string) {
+			<-release
+			done <- value
+		})
+	}()
+	// Verify
+	for i := 0; i < 2; i++ {
+		select {
+		case <-returned:
+		case <-time.After(500 * time.Millisecond):
+			t.Fatal("handler was run synchronously")
+		}
+	}
+	close(release)
+	assert.ElementsMatch(t, []interface{}{"settled", "resolved"}, []interface{}{awaitAPlus(t, done), awaitAPlus(t, done)})
+}
+
+func TestAPlus_2_2_6_HandlersRunInRegistrationOrder(t *testing.T) {
+	// Prepare
+	var lock sync.Mutex
+	var order []int
+	record := func(index int) {
+		lock.Lock()
+		defer lock.Unlock()
+		order = append(order, index)
+	}
+	release := make(chan struct{})
+	done := make(chan interface{})
+	// Test
+	fulfilled := NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		<-release
+		resolve()
+	})
+	rejected := NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		<-release
+		reject(errors.New("rejected"))
+	})
+	for i := 0; i < 3; i++ {
+		index := i
+		fulfilled.Then(func() { record(index) })
+		rejected.Catch(func(err error) { record(10 + index) })
+	}
+	close(release)
+	settledAPlus(fulfilled)
+	settledAPlus(rejected)
+	for i := 3; i < 5; i++ {
+		index := i
+		fulfilled.Then(func() { record(index) })
+		rejected.Then(nil, func(err error) { record(10 + index) })
+	}
+	fulfilled.Then(func() {
+		rejected.Catch(func(err error) { done <- nil })
+	})
+	// Verify
+	awaitAPlus(t, done)
+	lock.Lock()
+	defer lock.Unlock()
+	var fulfilledOrder, rejectedOrder []int
+	for _, index := range order {
+		if index < 10 {
+			fulfilledOrder = append(fulfilledOrder, index)
+		} else {
+			rejectedOrder = append(rejectedOrder, index)
+		}
+	}
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, fulfilledOrder)
+	assert.Equal(t, []int{10, 11, 12, 13, 14}, rejectedOrder)
+}
+
+func TestAPlus_2_2_7_ThenReturnsAPromise(t *testing.T) {
+	// Prepare
+	done := make(chan interface{}, 2)
+	rejection := errors.New("rejected")
+	// Test
+	Resolve(1).Then(func(value int) error {
+		return rejection
+	}).Catch(func(err error) {
+		done <- err
+	})
+	Reject(errors.New("handled")).Then(nil, func(err error) (interface{}, error) {
+		return "recovered", nil
+	}).Then(func(value string) {
+		done <- value
+	})
+	// Verify
+	assert.ElementsMatch(t, []interface{}{rejection, "recovered"}, []interface{}{awaitAPlus(t, done), awaitAPlus(t, done)})
+}
+
+func TestAPlus_2_3_1_PromiseResolvedWithItselfRejects(t *testing.T) {
+	// Prepare
+	ready := make(chan struct{})
+	done := make(chan interface{})
+	// Test
+	var promise Promise
+	promise = Resolve(1).Then(func(value int) Promise {
+		<-ready
+		return promise
+	})
+	close(ready)
+	promise.Catch(func(err *CycleError) {
+		done <- err
+	})
+	// Verify
+	assert.IsType(t, &CycleError{}, awaitAPlus(t, done))
+}
+
+func TestAPlus_2_3_2_AdoptsPromiseState(t *testing.T) {
+	// Prepare
+	release := make(chan struct{})
+	adopted := make(chan struct{}, 2)
+	done := make(chan interface{}, 2)
+	rejection := errors.New("rejected")
+	gated := func(settle func(resolve func(...interface{}), reject func(error))) Promise {
+		adopted <- struct{}{}
+		return NewPromise(func(resolve func(...interface{}), reject func(error)) {
+			<-release
+			settle(resolve, reject)
+		})
+	}
+	// Test
+	fulfilled := Resolve(1).Then(func(value int) Promise {
+		return gated(func(resolve func(...interface{}), reject func(error)) { resolve("adopted") })
+	})
+	rejected := Resolve(1).Then(func(value int) Promise {
+		return gated(func(resolve func(...interface{}), reject func(error)) { reject(rejection) })
+	})
+	<-adopted
+	<-adopted
+	assert.Equal(t, STATE_PENDING, fulfilled.State())
+	assert.Equal(t, STATE_PENDING, rejected.State())
+	close(release)
+	fulfilled.Then(func(value string) {
+		done <- value
+	})
+	rejected.Catch(func(err error) {
+		done <- err
+	})
+	// Verify
+	assert.ElementsMatch(t, []interface{}{"adopted", rejection}, []interface{}{awaitAPlus(t, done), awaitAPlus(t, done)})
+}
+
+func TestAPlus_2_3_3_AdoptsThenables(t *testing.T) {
+	// Prepare
+	done := make(chan interface{})
+	// Test
+	Resolve(1).Then(func(value int) (interface{}, error) {
+		return Resolve("adopted"), nil
+	}).Then(func(value string) {
+		done <- value
+	})
+	// Verify
+	assert.Equal(t, "adopted", awaitAPlus(t, done))
+}
+
+// A thenable implemented outside of the package, settling the way subscribe does.
+type aplusThenable struct {
+	subscribe func(onFulfilled func(values ...interface{}), onRejected func(err error))
+}
+
+func (this *aplusThenable) Subscribe(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+	this.subscribe(onFulfilled, onRejected)
+}
+
+func TestAPlus_2_3_3_3_AdoptsForeignThenables(t *testing.T) {
+	// Prepare
+	done := make(chan interface{}, 2)
+	rejection := errors.New("rejected")
+	// Test
+	Resolve(1).Then(func(value int) (*aplusThenable, error) {
+		return &aplusThenable{func(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+			go onFulfilled(&aplusThenable{func(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+				onFulfilled("adopted")
+			}})
+		}}, nil
+	}).Then(func(value string) {
+		done <- value
+	})
+	Resolve(1).Then(func(value int) (*aplusThenable, error) {
+		return &aplusThenable{func(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+			go onRejected(rejection)
+		}}, nil
+	}).Catch(func(err error) {
+		done <- err
+	})
+	// Verify
+	assert.ElementsMatch(t, []interface{}{"adopted", rejection}, []interface{}{awaitAPlus(t, done), awaitAPlus(t, done)})
+}
+
+func TestAPlus_2_3_3_3_3_FirstCallOfThenableWins(t *testing.T) {
+	// Prepare
+	rejection := errors.New("rejected")
+	// Test
+	fulfilled := settledAPlus(Resolve(1).Then(func(value int) (*aplusThenable, error) {
+		return &aplusThenable{func(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+			onFulfilled("first")
+			onRejected(rejection)
+			onFulfilled("second")
+		}}, nil
+	}))
+	rejected := settledAPlus(Resolve(1).Then(func(value int) (*aplusThenable, error) {
+		return &aplusThenable{func(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+			onRejected(rejection)
+			onFulfilled("fulfilled")
+			onRejected(errors.New("rejected again"))
+		}}, nil
+	}))
+	// Verify
+	assert.Equal(t, []interface{}{"first"}, fulfilled.Results())
+	assert.Equal(t, rejection, rejected.Error())
+}
+
+func TestAPlus_2_3_3_3_4_ThenablePanics(t *testing.T) {
+	// Test
+	panicked := settledAPlus(Resolve(1).Then(func(value int) (*aplusThenable, error) {
+		return &aplusThenable{func(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+			panic("boom")
+		}}, nil
+	}))
+	panickedAfterCall := settledAPlus(Resolve(1).Then(func(value int) (*aplusThenable, error) {
+		return &aplusThenable{func(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+			onFulfilled("fulfilled")
+			panic("boom")
+		}}, nil
+	}))
+	// Verify
+	assert.Equal(t, STATE_REJECTED, panicked.State())
+	assert.EqualError(t, panicked.Error(), "panic: boom")
+	assert.Equal(t, []interface{}{"fulfilled"}, panickedAfterCall.Results())
+}
+
+func TestAPlus_2_3_4_FulfillsWithNonThenables(t *testing.T) {
+	// Test
+	number := settledAPlus(Resolve(1).Then(func(value int) (int, error) {
+		return 2, nil
+	}))
+	empty := settledAPlus(Resolve(1).Then(func(value int) (interface{}, error) {
+		return nil, nil
+	}))
+	nilThenable := settledAPlus(Resolve(1).Then(func(value int) (*aplusThenable, error) {
+		return nil, nil
+	}))
+	// Verify
+	assert.Equal(t, []interface{}{2}, number.Results())
+	assert.Equal(t, []interface{}{nil}, empty.Results())
+	assert.Equal(t, []interface{}{(*aplusThenable)(nil)}, nilThenable.Results())
+}
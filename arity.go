@@ -0,0 +1,104 @@
+package promise
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// ArityMode decides what happens when a handler is called with a different number of values than it has parameters.
+//
+// With ARITY_LENIENT, the default, missing values are zero values and extra values are dropped. With ARITY_STRICT the
+// promise of the handler rejects with an *ArityMismatchError instead. In both modes the values left over after the
+// fixed parameters go to a variadic parameter, which is how a handler declares optional trailing parameters.
+type ArityMode string
+
+const (
+	ARITY_LENIENT = ArityMode("LENIENT")
+	ARITY_STRICT  = ArityMode("STRICT")
+)
+
+// ArityMismatchError is the rejection reason of a promise whose handler was called with the wrong number of values in
+// ARITY_STRICT mode. It is wrapped in a *ProgrammerError.
+type ArityMismatchError struct {
+	// The number of fixed parameters of the handler, leaving out resolve, reject and progress.
+	Expected int
+	Actual   int
+	Variadic bool
+}
+
+func (e *ArityMismatchError) Error() string {
+	if e.Variadic {
+		return fmt.Sprintf("arity mismatch: handler takes at least %d values, got %d", e.Expected, e.Actual)
+	}
+	return fmt.Sprintf("arity mismatch: handler takes %d values, got %d", e.Expected, e.Actual)
+}
+
+type arityHolder struct{ ArityMode }
+
+var globalArity atomic.Value
+
+// SetArityMode sets the mode of handlers that are not wrapped with Strict or Lenient. The returned function puts back
+// the mode that was in use before.
+func SetArityMode(mode ArityMode) (restore func()) {
+	previous := currentArityMode()
+	globalArity.Store(arityHolder{mode})
+	return func() {
+		globalArity.Store(arityHolder{previous})
+	}
+}
+
+func currentArityMode() ArityMode {
+	if holder, ok := globalArity.Load().(arityHolder); ok {
+		return holder.ArityMode
+	}
+	return ARITY_LENIENT
+}
+
+type arityHandler struct {
+	handler interface{}
+	mode    ArityMode
+}
+
+// Strict wraps a handler so that it is called in ARITY_STRICT mode, whatever the mode set with SetArityMode.
+func Strict(handler interface{}) interface{} {
+	return arityHandler{handlerFunc(handler), ARITY_STRICT}
+}
+
+// Lenient wraps a handler so that it is called in ARITY_LENIENT mode, whatever the mode set with SetArityMode.
+func Lenient(handler interface{}) interface{} {
+	return arityHandler{handlerFunc(handler), ARITY_LENIENT}
+}
+
+// Returns the function of a handler, which may be wrapped with Strict or Lenient.
+func handlerFunc(handler interface{}) interface{} {
+	if wrapped, isWrapped := handler.(arityHandler); isWrapped {
+		return wrapped.handler
+	}
+	return handler
+}
+
+// Fits the number of values passed to a handler to its parameters, see ArityMode.
+func (callback *callback) fitArity(params []reflect.Value) ([]reflect.Value, error) {
+	funcType := reflect.TypeOf(callback.callback)
+	isVariadic := funcType.IsVariadic()
+	fixed := len(callback.callbackInParamTypes)
+	if isVariadic {
+		fixed--
+	}
+
+	mode := callback.arity
+	if mode == "" {
+		mode = currentArityMode()
+	}
+	if len(params) == fixed || isVariadic && len(params) > fixed {
+		return params, nil
+	}
+	if mode == ARITY_STRICT {
+		return nil, Programmer(&ArityMismatchError{fixed, len(params), isVariadic})
+	}
+	if len(params) > fixed {
+		return params[:fixed], nil
+	}
+	return append(append([]reflect.Value(nil), params...), make([]reflect.Value, fixed - len(params))...), nil
+}
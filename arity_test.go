@@ -0,0 +1,83 @@
+package promise_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"reflect"
+	"testing"
+)
+
+func TestLenientArity(t *testing.T) {
+	// Test
+	padded := promise.Resolve(1).Then(func(a int, b string) ([]interface{}, error) {
+		return []interface{}{a, b}, nil
+	})
+	truncated := promise.Resolve(1, 2, 3).Then(func(a int) (int, error) {
+		return a, nil
+	})
+	zero := promise.Resolve().Then(func(a int) (int, error) {
+		return a, nil
+	})
+	variadic := promise.Resolve(1, 2, 3).Then(func(a int, rest ...int) ([]interface{}, error) {
+		return []interface{}{a, rest}, nil
+	})
+	// Verify
+	promisetest.AssertFulfilled(t, padded, settleTimeout, []interface{}{1, ""})
+	promisetest.AssertFulfilled(t, truncated, settleTimeout, 1)
+	promisetest.AssertFulfilled(t, zero, settleTimeout, 0)
+	promisetest.AssertFulfilled(t, variadic, settleTimeout, []interface{}{1, []int{2, 3}})
+}
+
+func TestStrictArityPerHandler(t *testing.T) {
+	// Test
+	mismatched := promise.Resolve(1, 2).Then(promise.Strict(func(a int) {}))
+	variadic := promise.Resolve(1).Then(promise.Strict(func(a int, rest ...int) ([]int, error) {
+		return rest, nil
+	}))
+	matching := promise.Resolve(1).Catch(promise.Strict(func(err error) {})).Then(promise.Strict(func(a int) (int, error) {
+		return a, nil
+	}))
+	// Verify
+	promisetest.AssertFulfilled(t, variadic, settleTimeout, []int{})
+	promisetest.AssertFulfilled(t, matching, settleTimeout, 1)
+	if promisetest.AssertRejected(t, mismatched, settleTimeout, reflect.TypeOf(&promise.ArityMismatchError{})) {
+		assert.True(t, promise.IsProgrammerError(mismatched.Error()))
+		assert.EqualError(t, mismatched.Error(), "arity mismatch: handler takes 1 values, got 2")
+	}
+}
+
+func TestStrictArityGlobally(t *testing.T) {
+	// Prepare
+	defer promise.SetArityMode(promise.ARITY_STRICT)()
+	// Test
+	strict := promise.Resolve(1).Then(func(a, b int) (int, error) {
+		return a + b, nil
+	})
+	lenient := promise.Resolve(1).Then(promise.Lenient(func(a, b int) (int, error) {
+		return a + b, nil
+	}))
+	// Verify
+	if promisetest.AssertRejected(t, strict, settleTimeout, reflect.TypeOf(&promise.ArityMismatchError{})) {
+		var mismatch *promise.ArityMismatchError
+		errors.As(strict.Error(), &mismatch)
+		assert.Equal(t, &promise.ArityMismatchError{Expected: 2, Actual: 1}, mismatch)
+	}
+	promisetest.AssertFulfilled(t, lenient, settleTimeout, 1)
+}
+
+func TestVariadicResolveRejectHandlerTakesEveryValue(t *testing.T) {
+	// Prepare
+	spread := func(resolve func(...interface{}), reject func(error), values ...interface{}) {
+		resolve(values...)
+	}
+	// Test
+	values := promise.Resolve(1, 2, 3).Spread(spread)
+	aggregate := promise.All(promise.Resolve(1), promise.Resolve(2)).Spread(spread)
+	strict := promise.Resolve(1, 2, 3).Spread(promise.Strict(spread))
+	// Verify
+	promisetest.AssertFulfilled(t, values, settleTimeout, 1, 2, 3)
+	promisetest.AssertFulfilled(t, aggregate, settleTimeout, 1, 2)
+	promisetest.AssertFulfilled(t, strict, settleTimeout, 1, 2, 3)
+}
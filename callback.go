@@ -1,13 +1,17 @@
 package promise
 
 import (
-	"fmt"
-	"github.com/thoas/go-funk"
+	"errors"
 	"reflect"
+	"sync/atomic"
 )
 
 type callbackState string
 
+// PromiseState is the type of the states reported by Promise.State, so that types outside the package can implement
+// Promise.
+type PromiseState = callbackState
+
 const (
 	// When the final value is not available yet. This is the only state that may transition to one of the other two states.
 	STATE_PENDING = callbackState("PENDING")
@@ -29,20 +33,26 @@ type callback struct {
 		bool
 		resolveIndex int
 		rejectIndex int
+		progressIndex int
 	}
 
 	isSignatureValidated bool
 	isReturningPromise bool
 	isReturningError bool
+	arity ArityMode
 }
 
 func newCallback(function interface{}) *callback {
+	var arity ArityMode
+	if wrapped, isWrapped := function.(arityHandler); isWrapped {
+		function, arity = wrapped.handler, wrapped.mode
+	}
 	if !IsFunc(function) { panic("callback is not a function") }
 	if !isValidCallbackSignature(function) { panic("callback signature is invalid") }
 
 	var inParamTypes, outParamTypes []reflect.Type
 	var isResolveRejectPresent, isReturningPromise, isReturningError bool
-	var resolveIndex, rejectIndex int
+	var resolveIndex, rejectIndex, progressIndex int
 	funcType := reflect.TypeOf(function)
 
 	inParamTypes = extractParameterTypes(funcType.In, funcType.NumIn())
@@ -56,7 +66,11 @@ func newCallback(function interface{}) *callback {
 					rejectFunc := funcType.In(rejectIndex)
 					isResolveRejectPresent = true
 
-					inParamTypes = append(inParamTypes[:resolveIndex], inParamTypes[rejectIndex+1:]...)
+					lastIndex := rejectIndex
+					if progressIndex = findProgressParameterIndex(funcType, rejectIndex); progressIndex >= 0 {
+						lastIndex = progressIndex
+					}
+					inParamTypes = append(inParamTypes[:resolveIndex], inParamTypes[lastIndex+1:]...)
 					outParamTypes = extractParameterTypes(resolveFunc.In, resolveFunc.NumIn())
 					outParamTypes = append(outParamTypes, extractParameterTypes(rejectFunc.In, rejectFunc.NumIn())...)
 				}
@@ -85,72 +99,109 @@ func newCallback(function interface{}) *callback {
 			bool
 			resolveIndex int
 			rejectIndex  int
-		}{isResolveRejectPresent, resolveIndex, rejectIndex},
+			progressIndex int
+		}{isResolveRejectPresent, resolveIndex, rejectIndex, progressIndex},
 
 		isReturningPromise: isReturningPromise,
 		isReturningError: isReturningError,
+		arity: arity,
 	}
 }
 
-func (callback *callback) call(params ...reflect.Value) (func(func(error, ...reflect.Value))) {
+// The handler is invoked through around, unless it is nil. A handler that around did not run fails with a
+// *ProgrammerError.
+func (callback *callback) call(progress func(interface{}), around func(handler func()) bool,
+	params ...reflect.Value) (func(func(error, ...reflect.Value))) {
+	invoke := func(params []reflect.Value) (results []reflect.Value, err error) {
+		if around == nil {
+			return callback.invoke(params)
+		}
+		if !around(func() { results, err = callback.invoke(params) }) {
+			err = Programmer(errors.New("an interceptor did not call next"))
+		}
+		return
+	}
+
 	return func(completed func(error, ...reflect.Value)) {
 		var (
 			results []reflect.Value
 			err error
 		)
 
+		if params, err = callback.fitArity(params); err != nil {
+			completed(err)
+			return
+		}
+
 		if callback.isResolveRejectPresent.bool {
-			await := make(chan interface{}, 1)
+			if params, err = callback.adaptParams(params); err != nil {
+				completed(err)
+				return
+			}
 
-			resolve := reflect.ValueOf(func(values ...interface{}) {
-				if isChanClosed(await) {
+			var settled int32
+
+			resolve := func(values ...interface{}) {
+				if !atomic.CompareAndSwapInt32(&settled, 0, 1) {
 					// TODO: print or log something out
 					return
 				}
-				close(await)
 
 				for _, value := range values {
 					results = append(results, reflect.ValueOf(value))
 				}
 
 				completed(nil, results...)
-			})
-			reject := reflect.ValueOf(func(err error) {
-				if isChanClosed(await) {
+			}
+			reject := func(err error) {
+				if !atomic.CompareAndSwapInt32(&settled, 0, 1) {
 					// TODO: print or log something out
 					return
 				}
-				close(await)
 
+				if err == nil {
+					err = Programmer(errors.New("promise rejected with a <nil> error"))
+				}
 				completed(err)
-			})
+			}
 
-			params = insertIntoSlice(params, resolve, callback.isResolveRejectPresent.resolveIndex).([]reflect.Value)
-			params = insertIntoSlice(params, reject, callback.isResolveRejectPresent.rejectIndex).([]reflect.Value)
+			params = insertIntoSlice(params, reflect.ValueOf(resolve), callback.isResolveRejectPresent.resolveIndex).([]reflect.Value)
+			params = insertIntoSlice(params, reflect.ValueOf(reject), callback.isResolveRejectPresent.rejectIndex).([]reflect.Value)
+			if callback.isResolveRejectPresent.progressIndex >= 0 {
+				params = insertIntoSlice(params, reflect.ValueOf(progress), callback.isResolveRejectPresent.progressIndex).([]reflect.Value)
+			}
 
-			go reflect.ValueOf(callback.callback).Call(params)
-		} else {
-			defer func() {
-				if r := recover(); r != nil {
-					fmt.Printf("Failed to execute: %s\nWith params: %s\n", callback.callback, fmt.Sprint(funk.Map(params, func(value reflect.Value) []interface{} {
-						return []interface{}{value.Type(), value.Interface()}
-					}).([][]interface{})))
-					panic(r)
+			currentScheduler().Schedule(func() {
+				if _, err := invoke(params); err != nil {
+					reject(err)
 				}
-			}()
-			if params != nil {
-				params = params[:len(callback.callbackInParamTypes)]
+			})
+		} else {
+			if params, err = callback.adaptParams(params); err != nil {
+				completed(err)
+				return
 			}
-			results = reflect.ValueOf(callback.callback).Call(params)
-			if callback.isReturningError {
+			results, err = invoke(params)
+			if err == nil && callback.isReturningError {
 				results, err = extractError(results)
 			}
+			results = dynamicValues(results)
 
 			completed(err, results...)
 		}
 	}
 }
 
+// Calls the callback, turning a panic into a *ProgrammerError.
+func (callback *callback) invoke(params []reflect.Value) (results []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			results, err = nil, newPanicError(r)
+		}
+	}()
+	return reflect.ValueOf(callback.callback).Call(params), nil
+}
+
 func findResolveParameterIndex(funcType reflect.Type) (index int, found bool) {
 	index, found = findRejectParameterIndex(funcType); index--
 	found = found && index >= 0 && funcType.In(index).Kind() == reflect.Func &&
@@ -167,6 +218,13 @@ func findRejectParameterIndex(funcType reflect.Type) (int, bool) {
 	return -1, false
 }
 
+func findProgressParameterIndex(funcType reflect.Type, rejectIndex int) int {
+	if index := rejectIndex + 1; index < funcType.NumIn() && funcType.In(index) == PROGRESS_TYPE {
+		return index
+	}
+	return -1
+}
+
 func extractError(results []reflect.Value) ([]reflect.Value, error) {
 	resultLen := len(results)
 	if resultLen > 0 {
@@ -176,6 +234,21 @@ func extractError(results []reflect.Value) ([]reflect.Value, error) {
 	return results, nil
 }
 
+// Values returned as interface{} are handed on with their dynamic type, the same way values passed to resolve are. A
+// nil interface becomes the same invalid reflect.Value that resolve(nil) produces.
+func dynamicValues(values []reflect.Value) []reflect.Value {
+	for i, value := range values {
+		if value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				values[i] = reflect.Value{}
+			} else {
+				values[i] = value.Elem()
+			}
+		}
+	}
+	return values
+}
+
 func insertIntoSlice(slice interface{}, value interface{}, index int) (interface{}) {
 	sliceValue := reflect.ValueOf(slice)
 	sliceType := reflect.SliceOf(reflect.TypeOf(slice).Elem())
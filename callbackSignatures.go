@@ -16,6 +16,7 @@ import (
 		func() (*Promise, error) { return nil, nil },
 
 		func(resolve func(...interface{}), reject func(error)) {},
+		func(resolve func(...interface{}), reject func(error), progress func(interface{})) {},
 		func(resolve func(...interface{}), reject func(error), values ...interface{}) {},
 		func(value interface{}, resolve func(), reject func(error)) {},
 		func(value interface{}, resolve func(...interface{}), reject func(error)) {},
@@ -57,6 +58,7 @@ var (
 	_FUNC_IN_OUT_PROMISE_ERROR = signature(func() (*Promise, error) { return nil, nil })
 
 	_FUNC_IN_RESOLVE_OBJS_REJECT_ERROR_OUT = signature(func(resolve func(...interface{}), reject func(error)) {})
+	_FUNC_IN_RESOLVE_OBJS_REJECT_ERROR_PROGRESS_OUT = signature(func(resolve func(...interface{}), reject func(error), progress func(interface{})) {})
 	_FUNC_IN_RESOLVE_OBJS_REJECT_ERROR_VARIADIC_OBJS_OUT = signature(func(resolve func(...interface{}), reject func(error), values ...interface{}) {})
 	_FUNC_IN_OBJ_RESOLVE_REJECT_ERROR_OUT = signature(func(value interface{}, resolve func(), reject func(error)) {})
 	_FUNC_IN_OBJS_RESOLVE_OBJS_REJECT_ERROR_OUT = signature(func(value interface{}, resolve func(...interface{}), reject func(error)) {})
@@ -77,8 +79,31 @@ var (
 	_FUNC_IN_ERROR_OUT_PROMISE_ERROR = signature(func(error) (*Promise, error) { return nil, nil })
 )
 
+var (
+	_THEN_SIGNATURES = []funcSignature{
+		_FUNC_IN_OBJS_RESOLVE_OBJS_REJECT_ERROR_OUT,
+		_FUNC_IN_OUT,
+		_FUNC_IN_VARIADIC_OBJS_OUT,
+		_FUNC_IN_VARIADIC_OBJS_OUT_ERROR,
+		_FUNC_IN_VARIADIC_OBJS_OUT_OBJ_ERROR,
+		_FUNC_IN_VARIADIC_OBJS_OUT_OBJS_ERROR,
+		_FUNC_IN_VARIADIC_OBJS_OUT_PROMISE,
+		_FUNC_IN_VARIADIC_OBJS_OUT_PROMISE_ERROR,
+	}
+	_CATCH_SIGNATURES = []funcSignature{
+		_FUNC_IN_ERROR_RESOLVE_OBJS_REJECT_ERROR_OUT,	// func(error, resolve func(...interface{}), reject func(error))
+		_FUNC_IN_ERROR_OUT, 							// func(error)
+		_FUNC_IN_ERROR_OUT_ERROR, 						// func(error) (error)
+		_FUNC_IN_ERROR_OUT_OBJ_ERROR, 					// func(error) (interface{}, error)
+		_FUNC_IN_ERROR_OUT_OBJS_ERROR, 					// func(error) ([]interface{}, error)
+		_FUNC_IN_ERROR_OUT_PROMISE, 					// func(error) (*Promise)
+		_FUNC_IN_ERROR_OUT_PROMISE_ERROR, 				// func(error) (*Promise, error)
+	}
+)
+
 var RESOLVER_TYPE = reflect.TypeOf(func(...interface{}) {})
 var REJECTOR_TYPE = reflect.TypeOf(func(error) {})
+var PROGRESS_TYPE = reflect.TypeOf(func(interface{}) {})
 var ERROR_TYPE = getFunctionNthParamType(func(error) {}, 1)
 var PROMISE_TYPE = getFunctionNthParamType(func(Promise) {}, 1)
 var withType = func(verifier func(reflect.Type) bool) (func(interface{}) bool) {
@@ -129,6 +154,14 @@ var signatureVerifiers = map[string]func(interface{}) bool {
 			funcType.In(1).NumIn() == 1 &&
 			funcType.In(1).In(0).Implements(ERROR_TYPE)
 	}),
+	// func(resolve func(...interface{}), reject func(error), progress func(interface{}))
+	_FUNC_IN_RESOLVE_OBJS_REJECT_ERROR_PROGRESS_OUT.name: withType(func(funcType reflect.Type) bool {
+		return funcType.NumIn() == 3 && funcType.NumOut() == 0 &&
+			funcType.In(0).Kind() == reflect.Func &&
+			funcType.In(0).NumIn() > 0 &&
+			funcType.In(1) == REJECTOR_TYPE &&
+			funcType.In(2) == PROGRESS_TYPE
+	}),
 	// func(resolve func(...interface{}), reject func(error), values ...interface{})
 	_FUNC_IN_RESOLVE_OBJS_REJECT_ERROR_VARIADIC_OBJS_OUT.name: withType(func(funcType reflect.Type) bool {
 		return funcType.NumIn() == 3 && funcType.NumOut() == 0 &&
@@ -193,7 +226,7 @@ var signatureVerifiers = map[string]func(interface{}) bool {
 	_FUNC_IN_VARIADIC_OBJS_OUT_ERROR.name: withType(func(funcType reflect.Type) bool {
 		return funcType.NumIn() > 0 && funcType.NumOut() == 1 &&
 			!funcType.In(0).Implements(ERROR_TYPE) &&
-			funcType.Out(1).Implements(ERROR_TYPE)
+			funcType.Out(0).Implements(ERROR_TYPE)
 	}),
 	// func(values ...interface{}) (interface{}, error)
 	_FUNC_IN_VARIADIC_OBJS_OUT_OBJ_ERROR.name: withType(func(funcType reflect.Type) bool {
@@ -266,7 +299,8 @@ var signatureVerifiers = map[string]func(interface{}) bool {
 }
 
 func assertFunctionSignature(function interface{}, signatures ...funcSignature) {
-	if !IsFunc(function) { panic("") } // TODO:
+	function = handlerFunc(function)
+	if !IsFunc(function) { panic(Programmer(fmt.Errorf("handler is not a function: %T", function))) }
 
 	for _, signature := range signatures {
 		isSignatureValid := signatureVerifiers[signature.name]
@@ -274,7 +308,7 @@ func assertFunctionSignature(function interface{}, signatures ...funcSignature)
 			return
 		}
 	}
-	panic("") // TODO:
+	panic(Programmer(fmt.Errorf("handler signature is not supported here: %T", function)))
 }
 
 func getFunctionNthParamType(function interface{}, nth int) reflect.Type {
@@ -285,6 +319,7 @@ func getFunctionNthParamType(function interface{}, nth int) reflect.Type {
 }
 
 func isValidCallbackSignature(callback interface{}) bool {
+	callback = handlerFunc(callback)
 	return IsFunc(callback) && funk.Find(funk.Values(signatureVerifiers), func(verifier func(function interface{}) bool) bool {
 		return verifier(callback)
 	}) != nil
@@ -0,0 +1,135 @@
+package promise_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"reflect"
+	"testing"
+)
+
+type testError struct {
+	code int
+}
+
+func (e *testError) Error() string {
+	return fmt.Sprintf("test error %d", e.code)
+}
+
+func TestCatchTypedHandlerSkipsOtherErrors(t *testing.T) {
+	// Test
+	p := promise.Reject(errors.New("plain")).
+		Catch(func(err *testError) (string, error) {
+			return "typed", nil
+		}).
+		Catch(func(err error) (string, error) {
+			return err.Error(), nil
+		})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "plain")
+}
+
+func TestCatchTypedHandlerUnwrapsError(t *testing.T) {
+	// Test
+	p := promise.Reject(fmt.Errorf("wrapped: %w", promise.Typed(&testError{42}))).
+		Catch(func(err *testError) (int, error) {
+			return err.code, nil
+		})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, 42)
+}
+
+func TestCatchIfPredicates(t *testing.T) {
+	// Prepare
+	sentinel := errors.New("sentinel")
+	// Test
+	p := promise.Reject(fmt.Errorf("wrapped: %w", sentinel)).
+		CatchIf(func(err error) bool { return false }, reflect.TypeOf(&testError{}), func(err error) (string, error) {
+			return "filtered", nil
+		}).
+		CatchIf(sentinel, func(err error) (string, error) {
+			return "sentinel", nil
+		})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "sentinel")
+}
+
+func TestCatchSkipsProgrammerErrors(t *testing.T) {
+	// Test
+	p := promise.NewPromise(func() {
+		panic("boom")
+	}).
+		Catch(func(err error) (string, error) {
+			return "generic", nil
+		}).
+		Catch(func(err *promise.ProgrammerError) (string, error) {
+			return err.Error(), nil
+		})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "panic: boom")
+}
+
+func TestCatchOperationalError(t *testing.T) {
+	// Prepare
+	notFound := errors.New("user not found")
+	caught := make(chan error, 1)
+	// Test
+	p := promise.NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		reject(promise.Operational(notFound))
+	}).
+		CatchIf(promise.IsOperationalError, func(err error) {
+			caught <- err
+		})
+	// Verify
+	if promisetest.AssertFulfilled(t, p, settleTimeout) {
+		err := <-caught
+		assert.True(t, errors.Is(err, notFound))
+		assert.False(t, promise.IsProgrammerError(err))
+	}
+}
+
+func TestThenErrorReturningHandlerRejects(t *testing.T) {
+	// Prepare
+	failure := errors.New("failure")
+	promises := make([]promise.Promise, 20)
+	// Test
+	for i := range promises {
+		promises[i] = promise.Resolve(i).Then(func(value int) error {
+			return failure
+		})
+	}
+	// Verify
+	for _, p := range promises {
+		promisetest.AssertRejected(t, p, settleTimeout, failure)
+	}
+}
+
+func TestThenRejectorSkipsProgrammerErrors(t *testing.T) {
+	// Test
+	p := promise.NewPromise(func() {
+		panic("boom")
+	}).
+		Then(nil, func(err error) (string, error) {
+			return "generic", nil
+		}).
+		Then(nil, func(err *promise.ProgrammerError) (string, error) {
+			return err.Error(), nil
+		})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "panic: boom")
+}
+
+func TestPanicWithProgrammerErrorIsNotWrapped(t *testing.T) {
+	// Prepare
+	invalid := promise.Programmer(errors.New("invalid"))
+	// Test
+	p := promise.NewPromise(func() {
+		panic(invalid)
+	})
+	// Verify
+	promisetest.AssertRejected(t, p, settleTimeout, func(err error) bool {
+		return err == invalid
+	})
+}
@@ -0,0 +1,67 @@
+package promise
+
+import (
+	"fmt"
+)
+
+// The E variants of NewPromise and of the Promise methods report invalid arguments, like a value that is not a
+// function or a handler with an unsupported signature, as a *ProgrammerError instead of panicking. No promise is
+// created or registered when they fail.
+
+func TryNewPromise(callbackFunc interface{}) (Promise, error) {
+	return checked(func() Promise {
+		return NewPromise(callbackFunc)
+	})
+}
+
+func (this *PromiseProto) ThenE(resolver interface{}, rejector ...interface{}) (Promise, error) {
+	return checked(func() Promise {
+		return this.Then(resolver, rejector...)
+	})
+}
+
+func (this *PromiseProto) TapE(callback interface{}) (Promise, error) {
+	return checked(func() Promise {
+		return this.Tap(callback)
+	})
+}
+
+func (this *PromiseProto) SpreadE(callback interface{}) (Promise, error) {
+	return checked(func() Promise {
+		return this.Spread(callback)
+	})
+}
+
+func (this *PromiseProto) CatchE(handler interface{}) (Promise, error) {
+	return checked(func() Promise {
+		return this.Catch(handler)
+	})
+}
+
+func (this *PromiseProto) CatchIfE(predicateOrTypes ...interface{}) (Promise, error) {
+	return checked(func() Promise {
+		return this.CatchIf(predicateOrTypes...)
+	})
+}
+
+func (this *PromiseProto) FinallyE(handler interface{}) (Promise, error) {
+	return checked(func() Promise {
+		return this.Finally(handler)
+	})
+}
+
+// Arguments are validated before anything is created, so recovering from the panic leaves no half set up promise
+// behind.
+func checked(create func() Promise) (promise Promise, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			promise = nil
+			if recoveredErr, isError := r.(error); isError && IsProgrammerError(recoveredErr) {
+				err = recoveredErr
+			} else {
+				err = Programmer(fmt.Errorf("%v", r))
+			}
+		}
+	}()
+	return create(), nil
+}
@@ -0,0 +1,54 @@
+package promise_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"testing"
+)
+
+func TestTryNewPromise(t *testing.T) {
+	// Test
+	invalid, err := promise.TryNewPromise("not a function")
+	valid, validErr := promise.TryNewPromise(func() {})
+	// Verify
+	assert.Nil(t, invalid)
+	assert.True(t, promise.IsProgrammerError(err))
+	assert.EqualError(t, err, "invalid callback signature: string")
+	assert.NotNil(t, valid)
+	assert.NoError(t, validErr)
+}
+
+func TestCheckedMethodsReportInvalidHandlers(t *testing.T) {
+	// Prepare
+	promise.TrackPending(true)
+	defer promise.TrackPending(false)
+	release := make(chan struct{})
+	defer close(release)
+	p := gated(release, func(resolve func(...interface{}), reject func(error)) {
+		resolve()
+	})
+	// Test
+	_, thenErr := p.ThenE(func(a, b, c int) (int, int) { return a, b })
+	_, rejectorsErr := p.ThenE(nil, func(error) {}, func(error) {})
+	_, catchErr := p.CatchE(func(value string) {})
+	_, catchIfErr := p.CatchIfE(42, func(err error) {})
+	_, finallyErr := p.FinallyE(func(value int) {})
+	_, tapErr := p.TapE(nil)
+	_, spreadErr := p.SpreadE("not a function")
+	// Verify
+	for _, err := range []error{thenErr, rejectorsErr, catchErr, catchIfErr, finallyErr, tapErr, spreadErr} {
+		assert.True(t, promise.IsProgrammerError(err), "%v", err)
+	}
+	assert.Equal(t, 1, promise.PendingCount())
+}
+
+func TestCheckedMethodsChainValidHandlers(t *testing.T) {
+	// Test
+	p, err := promise.Resolve("resolved").ThenE(func(value string) (string, error) {
+		return value + "!", nil
+	})
+	// Verify
+	assert.NoError(t, err)
+	promisetest.AssertFulfilled(t, p, settleTimeout, "resolved!")
+}
@@ -0,0 +1,191 @@
+package promise
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock is the source of time for every time based feature of the package: Delay, Timeout, the adoption stall
+// detection and the timestamps of the registry and of interceptor invocations.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer started by a Clock. *time.Timer implements it.
+type Timer interface {
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+type clockHolder struct{ Clock }
+
+var globalClock atomic.Value
+
+// SetClock replaces the clock used by the package, nil restores the real one. The returned function puts back the clock
+// that was in use before.
+func SetClock(clock Clock) (restore func()) {
+	if clock == nil {
+		clock = realClock{}
+	}
+	previous := currentClock()
+	globalClock.Store(clockHolder{clock})
+	return func() {
+		globalClock.Store(clockHolder{previous})
+	}
+}
+
+func currentClock() Clock {
+	if holder, ok := globalClock.Load().(clockHolder); ok {
+		return holder.Clock
+	}
+	return realClock{}
+}
+
+// FakeClock is a Clock that only moves when told to, for testing time based code without sleeping.
+type FakeClock struct {
+	lock   sync.Mutex
+	armed  *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	fire     func()
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	clock := &FakeClock{now: now}
+	clock.armed = sync.NewCond(&clock.lock)
+	return clock
+}
+
+func (this *FakeClock) Now() time.Time {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.now
+}
+
+func (this *FakeClock) After(d time.Duration) <-chan time.Time {
+	channel := make(chan time.Time, 1)
+	this.AfterFunc(d, func() {
+		channel <- this.Now()
+	})
+	return channel
+}
+
+func (this *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	timer := &fakeTimer{this, this.now.Add(d), f}
+	this.timers = append(this.timers, timer)
+	this.armed.Broadcast()
+	return timer
+}
+
+// Advance moves the clock forward by d, firing the timers that come due on the way in the order of their deadlines.
+// Timers started by the fired functions fire as well if they come due before the new time.
+func (this *FakeClock) Advance(d time.Duration) {
+	this.lock.Lock()
+	until := this.now.Add(d)
+	for {
+		next := -1
+		for i, timer := range this.timers {
+			if !timer.deadline.After(until) && (next < 0 || timer.deadline.Before(this.timers[next].deadline)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		timer := this.timers[next]
+		this.timers = append(this.timers[:next:next], this.timers[next+1:]...)
+		if timer.deadline.After(this.now) {
+			this.now = timer.deadline
+		}
+		this.lock.Unlock()
+
+		timer.fire()
+
+		this.lock.Lock()
+	}
+	this.now = until
+	this.lock.Unlock()
+}
+
+// BlockUntil waits until at least count timers are waiting to fire. Timers are often started from other goroutines,
+// like executors, so tests call it before advancing the clock.
+func (this *FakeClock) BlockUntil(count int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	for len(this.timers) < count {
+		this.armed.Wait()
+	}
+}
+
+func (this *fakeTimer) Stop() bool {
+	this.clock.lock.Lock()
+	defer this.clock.lock.Unlock()
+
+	for i, timer := range this.clock.timers {
+		if timer == this {
+			this.clock.timers = append(this.clock.timers[:i:i], this.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// TimeoutError is the rejection reason of a promise returned by Timeout that did not settle in time. It is wrapped in
+// an *OperationalError.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("promise timed out after %s", e.Timeout)
+}
+
+// Delay fulfills with values after d.
+func Delay(d time.Duration, values ...interface{}) Promise {
+	return NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		currentClock().AfterFunc(d, func() {
+			resolve(values...)
+		})
+	})
+}
+
+// Timeout settles like promise, unless it is still pending after d. Then it rejects with a *TimeoutError.
+func Timeout(d time.Duration, promise Promise) Promise {
+	if promise == nil { panic("promise cannot be <nil>") }
+
+	return NewPromise(func(resolve func(...interface{}), reject func(error), progress func(interface{})) {
+		timer := currentClock().AfterFunc(d, func() {
+			reject(Operational(&TimeoutError{d}))
+		})
+		promise.OnProgress(progress)
+		// Subscribed to rather than chained with Then, whose func(error) rejector would skip programmer errors.
+		promise.Subscribe(func(results ...interface{}) {
+			timer.Stop()
+			resolve(results...)
+		}, func(err error) {
+			timer.Stop()
+			reject(err)
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package promise_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFakeClockFiresTimersInOrder(t *testing.T) {
+	// Prepare
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := promise.NewFakeClock(start)
+	var fired []time.Duration
+	// Test
+	clock.AfterFunc(2 * time.Second, func() {
+		fired = append(fired, clock.Now().Sub(start))
+	})
+	clock.AfterFunc(time.Second, func() {
+		fired = append(fired, clock.Now().Sub(start))
+		clock.AfterFunc(time.Second / 2, func() {
+			fired = append(fired, clock.Now().Sub(start))
+		})
+	})
+	stopped := clock.AfterFunc(time.Second, func() {
+		fired = append(fired, 0)
+	})
+	assert.True(t, stopped.Stop())
+	clock.Advance(3 * time.Second)
+	// Verify
+	assert.Equal(t, []time.Duration{time.Second, 3 * time.Second / 2, 2 * time.Second}, fired)
+	assert.Equal(t, start.Add(3 * time.Second), clock.Now())
+	assert.False(t, stopped.Stop())
+}
+
+func TestDelayWithFakeClock(t *testing.T) {
+	// Prepare
+	clock := promise.NewFakeClock(time.Now())
+	defer promise.SetClock(clock)()
+	// Test
+	p := promise.Delay(time.Hour, "delayed")
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour - time.Nanosecond)
+	promisetest.AssertPending(t, p, 0)
+	clock.Advance(time.Nanosecond)
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "delayed")
+}
+
+func TestTimeoutWithFakeClock(t *testing.T) {
+	// Prepare
+	clock := promise.NewFakeClock(time.Now())
+	defer promise.SetClock(clock)()
+	// Test
+	p := promise.Timeout(time.Minute, promise.Delay(time.Hour, "late"))
+	clock.BlockUntil(2)
+	clock.Advance(time.Minute)
+	// Verify
+	if promisetest.AssertRejected(t, p, settleTimeout, reflect.TypeOf(&promise.TimeoutError{})) {
+		var timeoutErr *promise.TimeoutError
+		errors.As(p.Error(), &timeoutErr)
+		assert.Equal(t, time.Minute, timeoutErr.Timeout)
+		assert.True(t, promise.IsOperationalError(p.Error()))
+	}
+}
+
+func TestTimeoutSettlesLikePromise(t *testing.T) {
+	// Prepare
+	clock := promise.NewFakeClock(time.Now())
+	defer promise.SetClock(clock)()
+	// Test
+	p := promise.Timeout(time.Hour, promise.Delay(time.Minute, "early"))
+	clock.BlockUntil(2)
+	clock.Advance(time.Minute)
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "early")
+}
+
+func TestTimeoutPassesProgrammerErrorsThrough(t *testing.T) {
+	// Prepare
+	clock := promise.NewFakeClock(time.Now())
+	defer promise.SetClock(clock)()
+	// Test
+	p := promise.Timeout(time.Minute, promise.NewPromise(func() {
+		panic("boom")
+	}))
+	// Verify
+	promisetest.AssertRejected(t, p, settleTimeout, reflect.TypeOf(&promise.ProgrammerError{}))
+}
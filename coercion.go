@@ -0,0 +1,111 @@
+package promise
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TypeMismatchError is the rejection reason of a promise whose handler cannot take the values it was called with. It
+// is wrapped in a *ProgrammerError. Scan and ScanStruct return it as it is.
+type TypeMismatchError struct {
+	// The position of the handler parameter, counting from 0 and leaving out resolve, reject and progress. For Scan, the
+	// position of the destination, and for ScanStruct, the position of the field among the ones scanned.
+	Index    int
+	Expected reflect.Type
+	Actual   reflect.Type
+	// Set by Scan and ScanStruct to what the result was scanned into, like "destination 1" or "field Name".
+	Target   string
+}
+
+func (e *TypeMismatchError) Error() string {
+	if e.Target != "" {
+		return fmt.Sprintf("scan: %s expects %s, got %s", e.Target, e.Expected, e.Actual)
+	}
+	return fmt.Sprintf("type mismatch: handler parameter %d expects %s, got %s", e.Index, e.Expected, e.Actual)
+}
+
+var INTERFACES_TYPE = reflect.TypeOf([]interface{}{})
+
+// Fits the values passed to a handler to its parameter types:
+//   - a nil value becomes the zero value of the parameter
+//   - a value assignable to the parameter is passed as it is
+//   - a number is converted to another numeric type, as long as an integer parameter can hold it exactly
+//   - a string is converted to another string type
+//   - a []interface{}, like the results of All, is spread over a variadic parameter of another type
+// Anything else is a *TypeMismatchError. The params may be the results of a settled promise, so they are copied rather
+// than changed.
+func (callback *callback) adaptParams(params []reflect.Value) ([]reflect.Value, error) {
+	funcType := reflect.TypeOf(callback.callback)
+	paramTypes := callback.callbackInParamTypes
+	// Resolve, reject and progress are left out of callbackInParamTypes, so a variadic parameter is still the last one.
+	isVariadic := funcType.IsVariadic()
+	paramType := func(index int) reflect.Type {
+		if isVariadic && index >= len(paramTypes) - 1 {
+			return paramTypes[len(paramTypes) - 1].Elem()
+		}
+		return paramTypes[index]
+	}
+
+	adapted := make([]reflect.Value, 0, len(params))
+	for i, param := range params {
+		if isVariadic && i == len(paramTypes) - 1 && i == len(params) - 1 && param.IsValid() &&
+			param.Type() == INTERFACES_TYPE && !INTERFACES_TYPE.AssignableTo(paramType(i)) {
+			for j := 0; j < param.Len(); j++ {
+				adapted = append(adapted, dynamicValues([]reflect.Value{param.Index(j)})...)
+			}
+			break
+		}
+		adapted = append(adapted, param)
+	}
+
+	for i, param := range adapted {
+		if i >= len(paramTypes) && !isVariadic {
+			break
+		}
+		expected := paramType(i)
+		switch {
+		case !param.IsValid():
+			adapted[i] = reflect.Zero(expected)
+		case param.Type().AssignableTo(expected):
+		case isConvertible(param, expected):
+			adapted[i] = param.Convert(expected)
+		default:
+			return nil, Programmer(&TypeMismatchError{i, expected, param.Type(), ""})
+		}
+	}
+	return adapted, nil
+}
+
+func isConvertible(value reflect.Value, to reflect.Type) bool {
+	from := value.Type()
+	switch {
+	case from.Kind() == reflect.String && to.Kind() == reflect.String:
+		return true
+	case !isNumeric(from) || !isNumeric(to):
+		return false
+	case to.Kind() == reflect.Float32 || to.Kind() == reflect.Float64:
+		return true
+	}
+	converted := value.Convert(to)
+	return isNegative(converted) == isNegative(value) && converted.Convert(from).Interface() == value.Interface()
+}
+
+func isNegative(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() < 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() < 0
+	}
+	return false
+}
+
+func isNumeric(valueType reflect.Type) bool {
+	switch valueType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package promise_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"reflect"
+	"testing"
+)
+
+type userId string
+
+func TestResultsAreConvertedToHandlerTypes(t *testing.T) {
+	// Test
+	widened := promise.Resolve(42).Then(func(value int64) (int64, error) {
+		return value, nil
+	})
+	float := promise.Resolve(42).Then(func(value float64) (float64, error) {
+		return value, nil
+	})
+	narrowed := promise.Resolve(2.0).Then(func(value uint8) (uint8, error) {
+		return value, nil
+	})
+	named := promise.Resolve("u-1").Then(func(value userId) (userId, error) {
+		return value, nil
+	})
+	spread := promise.All(promise.Resolve(1), promise.Resolve(2)).Then(func(values ...int) ([]int, error) {
+		return values, nil
+	})
+	// Verify
+	promisetest.AssertFulfilled(t, widened, settleTimeout, int64(42))
+	promisetest.AssertFulfilled(t, float, settleTimeout, float64(42))
+	promisetest.AssertFulfilled(t, narrowed, settleTimeout, uint8(2))
+	promisetest.AssertFulfilled(t, named, settleTimeout, userId("u-1"))
+	promisetest.AssertFulfilled(t, spread, settleTimeout, []int{1, 2})
+}
+
+func TestTypeMismatchRejects(t *testing.T) {
+	for name, test := range map[string]struct {
+		promise  promise.Promise
+		expected reflect.Type
+		actual   reflect.Type
+	}{
+		"string to int": {
+			promise.Resolve("42").Then(func(value int) {}),
+			reflect.TypeOf(0), reflect.TypeOf(""),
+		},
+		"fraction to int": {
+			promise.Resolve(1.5).Then(func(value int) {}),
+			reflect.TypeOf(0), reflect.TypeOf(0.0),
+		},
+		"overflow": {
+			promise.Resolve(300).Then(func(value int8) {}),
+			reflect.TypeOf(int8(0)), reflect.TypeOf(0),
+		},
+		"negative to unsigned": {
+			promise.Resolve(-1).Then(func(value uint) {}),
+			reflect.TypeOf(uint(0)), reflect.TypeOf(0),
+		},
+		"spread element": {
+			promise.All(promise.Resolve(1), promise.Resolve("2")).Then(func(values ...int) {}),
+			reflect.TypeOf(0), reflect.TypeOf(""),
+		},
+	} {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			// Verify
+			if promisetest.AssertRejected(t, test.promise, settleTimeout, promise.IsProgrammerError) {
+				var mismatch *promise.TypeMismatchError
+				if assert.True(t, errors.As(test.promise.Error(), &mismatch)) {
+					assert.Equal(t, test.expected, mismatch.Expected)
+					assert.Equal(t, test.actual, mismatch.Actual)
+				}
+			}
+		})
+	}
+}
+
+func TestTypeMismatchNamesHandlerParameter(t *testing.T) {
+	// Test
+	p := promise.Resolve(1, "two").Then(func(first int, second int) {})
+	// Verify
+	promisetest.AssertRejected(t, p, settleTimeout, func(err error) bool {
+		var mismatch *promise.TypeMismatchError
+		return errors.As(err, &mismatch) && mismatch.Error() == "type mismatch: handler parameter 1 expects int, got string"
+	})
+}
+
+func TestVariadicResolveRejectHandlerIsConverted(t *testing.T) {
+	// Test
+	p := promise.Resolve(1).Spread(func(resolve func(...interface{}), reject func(error), values ...interface{}) {
+		resolve(values...)
+	})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, 1)
+}
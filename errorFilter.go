@@ -0,0 +1,44 @@
+package promise
+
+import (
+	"errors"
+	"reflect"
+)
+
+type errorFilter []func(error) bool
+
+// Builds a filter out of CatchIf predicates. Each predicate may be a func(error) bool, a reflect.Type of an error
+// (matched with errors.As) or an error value (matched with errors.Is).
+func newErrorFilter(predicateOrTypes ...interface{}) (filter errorFilter) {
+	for _, predicateOrType := range predicateOrTypes {
+		switch predicate := predicateOrType.(type) {
+		case func(error) bool:
+			filter = append(filter, predicate)
+		case reflect.Type:
+			if !predicate.Implements(ERROR_TYPE) { panic("CatchIf type predicate must implement error: " + predicate.String()) }
+			filter = append(filter, func(err error) bool {
+				_, matches := matchError(err, predicate)
+				return matches
+			})
+		case error:
+			filter = append(filter, func(err error) bool {
+				return errors.Is(err, predicate)
+			})
+		default:
+			panic("CatchIf predicate must be a func(error) bool, an error type or an error value")
+		}
+	}
+	return
+}
+
+func (filter errorFilter) matches(err error) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, predicate := range filter {
+		if predicate(err) {
+			return true
+		}
+	}
+	return false
+}
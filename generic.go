@@ -0,0 +1,59 @@
+package promise
+
+import (
+	"context"
+	"reflect"
+)
+
+// Value waits for p to fulfill with a single value and returns it as a T, converted like Scan does.
+func Value[T any](ctx context.Context, p Promise) (T, error) {
+	var value T
+	err := p.Scan(ctx, &value)
+	return value, err
+}
+
+// Values2 waits for p to fulfill with two values and returns them as an A and a B, converted like Scan does.
+func Values2[A, B any](ctx context.Context, p Promise) (A, B, error) {
+	var (
+		a A
+		b B
+	)
+	err := p.Scan(ctx, &a, &b)
+	return a, b, err
+}
+
+// Values3 waits for p to fulfill with three values and returns them as an A, a B and a C, converted like Scan does.
+func Values3[A, B, C any](ctx context.Context, p Promise) (A, B, C, error) {
+	var (
+		a A
+		b B
+		c C
+	)
+	err := p.Scan(ctx, &a, &b, &c)
+	return a, b, c, err
+}
+
+// ThenT is Then with a handler whose types are checked by the compiler. The value p fulfills with is converted to an A
+// the way handler parameters are, a value that does not fit rejects the returned promise with a *TypeMismatchError.
+// The arity mode applies as it does to other handlers.
+//
+// fn is never checked against the handler signatures, the compiler has done that. It is wrapped in a
+// func(...interface{}) (interface{}, error) that is registered with Then, so that interceptors, the scheduler, the
+// pending registry and the adoption of a returned Thenable treat it like any other handler. The wrapper's signature
+// is the only one verified.
+func ThenT[A, B any](p Promise, fn func(A) (B, error)) Promise {
+	if fn == nil { panic("handler cannot be <nil>") }
+
+	return p.Then(func(values ...interface{}) (interface{}, error) {
+		var a A
+		if len(values) != 1 && currentArityMode() == ARITY_STRICT {
+			return nil, Programmer(&ArityMismatchError{1, len(values), false})
+		}
+		if len(values) > 0 {
+			if err := assignResult(reflect.ValueOf(&a).Elem(), reflect.ValueOf(values[0]), 0, ""); err != nil {
+				return nil, Programmer(err)
+			}
+		}
+		return fn(a)
+	})
+}
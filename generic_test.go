@@ -0,0 +1,56 @@
+package promise_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	// Test
+	value, err := promise.Value[int64](context.Background(), promise.Resolve(42))
+	_, mismatchErr := promise.Value[int](context.Background(), promise.Resolve("42"))
+	// Verify
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), value)
+	var mismatch *promise.TypeMismatchError
+	assert.True(t, errors.As(mismatchErr, &mismatch))
+}
+
+func TestValues2And3(t *testing.T) {
+	// Test
+	name, count, err := promise.Values2[string, int](context.Background(), promise.Resolve("name", 2))
+	a, b, c, err3 := promise.Values3[string, int, interface{}](context.Background(), promise.Resolve("a", 1, nil))
+	// Verify
+	assert.NoError(t, err)
+	assert.Equal(t, "name", name)
+	assert.Equal(t, 2, count)
+	assert.NoError(t, err3)
+	assert.Equal(t, "a", a)
+	assert.Equal(t, 1, b)
+	assert.Nil(t, c)
+}
+
+func TestThenT(t *testing.T) {
+	// Test
+	converted := promise.ThenT(promise.Resolve(42), func(value int64) (string, error) {
+		return strconv.FormatInt(value, 10), nil
+	})
+	length := promise.ThenT(converted, func(value string) (int, error) {
+		return len(value), nil
+	})
+	mismatched := promise.ThenT(promise.Resolve("x"), func(value int) (int, error) {
+		return value, nil
+	})
+	// Verify
+	promisetest.AssertFulfilled(t, length, settleTimeout, 2)
+	promisetest.AssertRejected(t, mismatched, settleTimeout, reflect.TypeOf(&promise.TypeMismatchError{}))
+	value, err := promise.Value[string](context.Background(), converted)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", value)
+}
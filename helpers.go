@@ -1,7 +1,15 @@
 package promise
 
-import "github.com/thoas/go-funk"
+import (
+	"context"
+	"fmt"
+	"github.com/thoas/go-funk"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
 
+// Resolve fulfills with values. A single Promise or Thenable is adopted instead: Resolve(Resolve(1)) fulfills with 1.
 func Resolve(values ...interface{}) Promise {
 	return NewPromise(func(resolve func(...interface{}), reject func(error)) {
 		resolve(values...)
@@ -14,106 +22,253 @@ func Reject(err error) Promise {
 	})
 }
 
+// Map passes every element of values to mapper and waits for the results with All. The mapper may return a Promise, a
+// Thenable or a plain value.
 func Map(values interface{}, mapper interface{}) Promise {
-	promises := funk.Map(values, mapper).([]Promise)
-	return All(promises...)
+	mapped := reflect.ValueOf(funk.Map(values, mapper))
+	thenables := make([]Thenable, mapped.Len())
+	for i := range thenables {
+		switch value := mapped.Index(i).Interface().(type) {
+		case nil:
+		case Thenable:
+			thenables[i] = value
+		default:
+			thenables[i] = Resolve(value)
+		}
+	}
+	return All(thenables...)
 }
 
-func Race(promises ...Promise) Promise {
-	return NewPromise(func(resolve func(Promise), reject func(error)) {
-		racing := true
-		for _, promise := range promises {
-			pending := true
-			promise.Then(func(results ...interface{}) {
-				if racing && pending {
-					racing, pending = false, false
-					resolve(promise)
-				}
-			}, func(err error) {
-				if racing && pending {
-					racing, pending = false, false
-					reject(err)
-				}
-			})
+// The combinators take any Thenable and adopt it. A nil one counts as a promise fulfilled with no values.
+func adoptAll(thenables []Thenable) []Promise {
+	promises := make([]Promise, len(thenables))
+	for i, thenable := range thenables {
+		if thenable == nil {
+			promises[i] = Resolve()
+		} else {
+			promises[i] = Adopt(thenable)
 		}
+	}
+	return promises
+}
+
+// Race settles like the first of the thenables to settle, fulfilling with all of its results or rejecting with its
+// error. The other thenables are ignored, see RaceContext for cancelling them. A nil thenable counts as a promise
+// fulfilled with no values. Without thenables the race stays pending.
+func Race(thenables ...Thenable) Promise {
+	return NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		race(thenables, func(index int, results []interface{}, err error) {
+			if err != nil {
+				reject(err)
+			} else {
+				resolve(results...)
+			}
+		})
 	})
 }
 
-func Any(promises ...Promise) Promise {
-	return NewPromise(func(resolve func(...[]interface{}), reject func(...error)) {
-		failCountDown := len(promises)
-		orderedFailures := make([]error, failCountDown)
-		await := make(chan interface{}, failCountDown)
+// IndexedError is the rejection reason of RaceIndexed: the error of the promise that won and its position.
+type IndexedError struct {
+	Index int
+	Err   error
+}
 
-		if len(promises) == 0 { // TODO: Verify behavior
-			resolve(nil)
-			return
-		}
+func (e *IndexedError) Error() string {
+	return fmt.Sprintf("promise %d: %v", e.Index, e.Err)
+}
 
-		for index, promise := range promises {
-			promise.Then(func(results ...interface{}) {
-				if isChanClosed(await) { return }
-				close(await)
-				resolve(results)
-			}, func(err error) {
-				if isChanClosed(await) { return }
-				await <- func() (int, error) { return index, err}
-			})
-		}
+func (e *IndexedError) Unwrap() error {
+	return e.Err
+}
 
-		for {
-			select {
-			case funcError, open := <-await:
-				if !open { break }
-				index, err := funcError.(func() (int, error))()
-				orderedFailures[index] = err
-				failCountDown--
+// RaceIndexed is Race reporting the position of the thenable that won. It fulfills with the position followed by the
+// results of the winner, or rejects with an *IndexedError.
+func RaceIndexed(thenables ...Thenable) Promise {
+	return NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		race(thenables, func(index int, results []interface{}, err error) {
+			if err != nil {
+				reject(&IndexedError{index, err})
+			} else {
+				resolve(append([]interface{}{index}, results...)...)
 			}
+		})
+	})
+}
 
-			if failCountDown == 0 {
-				reject(orderedFailures...)
-				break
+// RaceContext is Race for racers that can be cancelled. Each racer is started with a context that is cancelled as soon
+// as the race is settled, so the losers can stop their work. If ctx is done before any racer settles, the race
+// rejects with ctx.Err().
+func RaceContext(ctx context.Context, racers ...func(ctx context.Context) Promise) Promise {
+	raceCtx, cancel := context.WithCancel(ctx)
+	thenables := make([]Thenable, len(racers), len(racers) + 1)
+	for i, racer := range racers {
+		thenables[i] = racer(raceCtx)
+	}
+	thenables = append(thenables, NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		<-raceCtx.Done()
+		reject(raceCtx.Err())
+	}))
+
+	return NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		race(thenables, func(index int, results []interface{}, err error) {
+			cancel()
+			if err != nil {
+				reject(err)
+			} else {
+				resolve(results...)
 			}
-		}
+		})
 	})
 }
 
-func All(promises ...Promise) (result Promise) {
-	return NewPromise(func(resolve func(...interface{}), reject func(...error)) {
-		promisesCountDown := len(promises)
-		promiseResults := make([]interface{}, promisesCountDown)
-		await := make(chan interface{}, promisesCountDown)
+// Calls won once, with the outcome of the first of the thenables to settle.
+func race(thenables []Thenable, won func(index int, results []interface{}, err error)) {
+	var settled int32
+	for index, promise := range adoptAll(thenables) {
+		index := index
+		promise.Subscribe(func(results ...interface{}) {
+			if atomic.CompareAndSwapInt32(&settled, 0, 1) {
+				won(index, results, nil)
+			}
+		}, func(err error) {
+			if atomic.CompareAndSwapInt32(&settled, 0, 1) {
+				won(index, nil, err)
+			}
+		})
+	}
+}
 
-		for index, promise := range promises {
-			if promise == nil {
-				if isChanClosed(await) { break }
-				await <- func() (int, interface{}) { return index, nil }
-				continue
+// Any fulfills with the results of the first fulfilled thenable. If all of the thenables reject, or there are none, the
+// returned promise rejects with an *AggregateError.
+func Any(thenables ...Thenable) Promise {
+	return NewPromise(func(resolve func(...interface{}), reject func(error), progress func(interface{})) {
+		some(1, thenables, func(fulfilled [][]interface{}) {
+			resolve(fulfilled[0]...)
+		}, reject, progress)
+	})
+}
+
+// Some fulfills with a []interface{} holding the first result of the first count fulfilled thenables, in the order they
+// fulfilled. As soon as too many thenables have rejected for count to be reached, it rejects with an *AggregateError.
+// With fewer thenables than count it rejects at once with an *AggregateError holding no errors. Like All, it reports a Progress after each settled thenable.
+func Some(count int, thenables ...Thenable) Promise {
+	return NewPromise(func(resolve func(...interface{}), reject func(error), progress func(interface{})) {
+		some(count, thenables, func(fulfilled [][]interface{}) {
+			values := make([]interface{}, len(fulfilled))
+			for i, results := range fulfilled {
+				if len(results) > 0 {
+					values[i] = results[0]
+				}
 			}
+			resolve(values)
+		}, reject, progress)
+	})
+}
 
-			promise.Then(func (result interface{}) {
-				if isChanClosed(await) { return }
-				await <- func() (int, interface{}) { return index, result }
-			}, func(err error) {
-				if isChanClosed(await) { return }
-				close(await)
-				reject(err)
-			})
-		}
+func some(count int, thenables []Thenable, resolve func([][]interface{}), reject func(error), progress func(interface{})) {
+	promises := adoptAll(thenables)
+	if count > len(promises) {
+		reject(newShortfallError(count, len(promises)))
+		return
+	}
+	if count <= 0 {
+		resolve(nil)
+		return
+	}
 
-		for {
-			select {
-			case funcResult, open := <- await:
-				if !open { break }
-				index, result := funcResult.(func() (int, interface{}))()
-				promiseResults[index] = result
-				promisesCountDown--
+	var lock sync.Mutex
+	settled := false
+	fulfilled := make([][]interface{}, 0, count)
+	failures := make([]error, len(promises))
+	failCount := 0
+
+	for index, promise := range promises {
+		index := index
+		promise.Subscribe(func(results ...interface{}) {
+			lock.Lock()
+			if settled {
+				lock.Unlock()
+				return
 			}
+			fulfilled = append(fulfilled, results)
+			isResolved := len(fulfilled) == count
+			settled = isResolved
+			settledCount := len(fulfilled) + failCount
+			lock.Unlock()
 
-			if promisesCountDown == 0 {
-				resolve(promiseResults)
-				break
+			progress(Progress{settledCount, len(promises)})
+			if isResolved { resolve(fulfilled) }
+		}, func(err error) {
+			lock.Lock()
+			if settled {
+				lock.Unlock()
+				return
 			}
+			failures[index] = err
+			failCount++
+			isRejected := len(promises) - failCount < count
+			settled = isRejected
+			settledCount := len(fulfilled) + failCount
+			lock.Unlock()
+
+			progress(Progress{settledCount, len(promises)})
+			if isRejected { reject(newAggregateError(failures)) }
+		})
+	}
+}
+
+// Tuple holds the results of a promise that fulfilled with more than one value. All puts one in its aggregate for such
+// a promise and Spread passes its values as separate arguments.
+type Tuple []interface{}
+
+// All fulfills with a []interface{} holding the outcome of each thenable at its position: the value it fulfilled with,
+// a Tuple if it fulfilled with several values or nil if it fulfilled with none. A nil thenable counts as a promise
+// fulfilled with no values. All rejects with the first error and fulfills at once if there are no thenables. While
+// pending it reports a Progress after each settled thenable.
+func All(thenables ...Thenable) Promise {
+	return NewPromise(func(resolve func(...interface{}), reject func(error), progress func(interface{})) {
+		promises := adoptAll(thenables)
+		if len(promises) == 0 {
+			resolve([]interface{}{})
+			return
+		}
+
+		var lock sync.Mutex
+		settled := false
+		values := make([]interface{}, len(promises))
+		pending := len(promises)
+
+		for index, promise := range promises {
+			index := index
+			promise.Subscribe(func(results ...interface{}) {
+				lock.Lock()
+				if settled {
+					lock.Unlock()
+					return
+				}
+				switch len(results) {
+				case 0:
+				case 1:
+					values[index] = results[0]
+				default:
+					values[index] = Tuple(results)
+				}
+				pending--
+				isResolved := pending == 0
+				settled = isResolved
+				settledCount := len(promises) - pending
+				lock.Unlock()
+
+				progress(Progress{settledCount, len(promises)})
+				if isResolved { resolve(values) }
+			}, func(err error) {
+				lock.Lock()
+				isRejected := !settled
+				settled = true
+				lock.Unlock()
+
+				if isRejected { reject(err) }
+			})
 		}
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,215 @@
+package promise_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// How long the tests of the external test package wait for a promise to settle.
+const settleTimeout = 500 * time.Millisecond
+
+func pending() promise.Promise {
+	return promise.NewPromise(func(resolve func(...interface{}), reject func(error)) {})
+}
+
+// Creates a promise that settles with settle once release is closed.
+func gated(release chan struct{}, settle func(resolve func(...interface{}), reject func(error))) promise.Promise {
+	return promise.NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		<-release
+		settle(resolve, reject)
+	})
+}
+
+func TestAnyRejectsWithAggregateError(t *testing.T) {
+	// Prepare
+	first, second := errors.New("first"), errors.New("second")
+	// Test
+	p := promise.Any(promise.Reject(first), promise.Reject(second))
+	// Verify
+	if promisetest.AssertRejected(t, p, settleTimeout, reflect.TypeOf(&promise.AggregateError{})) {
+		assert.Equal(t, []error{first, second}, p.Error().(*promise.AggregateError).Errors())
+		assert.True(t, errors.Is(p.Error(), second))
+	}
+}
+
+func TestAnyResolvesWithFirstFulfilled(t *testing.T) {
+	// Test
+	p := promise.Any(promise.Reject(errors.New("rejected")), promise.Resolve("resolved"))
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "resolved")
+}
+
+func TestSomeResolvesWithCountValues(t *testing.T) {
+	// Test
+	p := promise.Some(2, promise.Resolve(1), promise.Reject(errors.New("rejected")), promise.Resolve(3))
+	// Verify
+	if promisetest.AssertFulfilled(t, p, settleTimeout) {
+		assert.ElementsMatch(t, []interface{}{1, 3}, p.Results()[0])
+	}
+}
+
+func TestSomeRejectsWhenCountIsUnreachable(t *testing.T) {
+	// Test
+	p := promise.Some(2, promise.Resolve(1), promise.Reject(errors.New("first")), promise.Reject(errors.New("second")))
+	// Verify
+	promisetest.AssertRejected(t, p, settleTimeout, func(err error) bool {
+		var aggregate *promise.AggregateError
+		return errors.As(err, &aggregate) && len(aggregate.Errors()) == 2
+	})
+}
+
+func TestSomeRejectsWhenCountExceedsThenables(t *testing.T) {
+	// Test
+	some := promise.Some(3, promise.Resolve(1), promise.Resolve(2))
+	anyOf := promise.Any()
+	// Verify
+	for _, p := range []promise.Promise{some, anyOf} {
+		if promisetest.AssertRejected(t, p, settleTimeout, reflect.TypeOf(&promise.AggregateError{})) {
+			var aggregate *promise.AggregateError
+			errors.As(p.Error(), &aggregate)
+			assert.Empty(t, aggregate.Errors())
+		}
+	}
+	assert.EqualError(t, some.Error(), "aggregate error: need 3 of 2 promises to fulfill")
+}
+
+func TestRaceSettlesLikeWinner(t *testing.T) {
+	// Prepare
+	failure := errors.New("failure")
+	// Test
+	fulfilled := promise.Race(pending(), promise.Resolve("a", 1))
+	rejected := promise.Race(pending(), promise.Reject(failure))
+	// Verify
+	promisetest.AssertFulfilled(t, fulfilled, settleTimeout, "a", 1)
+	promisetest.AssertRejected(t, rejected, settleTimeout, failure)
+}
+
+func TestRaceIsRaceFree(t *testing.T) {
+	// Prepare
+	const racers = 50
+	release := make(chan struct{})
+	thenables := make([]promise.Thenable, racers)
+	for i := range thenables {
+		i := i
+		thenables[i] = gated(release, func(resolve func(...interface{}), reject func(error)) {
+			resolve(i)
+		})
+	}
+	// Test
+	p := promise.RaceIndexed(thenables...)
+	close(release)
+	// Verify
+	if promisetest.AssertFulfilled(t, p, settleTimeout) {
+		assert.Equal(t, p.Results()[0], p.Results()[1])
+	}
+}
+
+func TestRaceIndexedRejection(t *testing.T) {
+	// Prepare
+	failure := errors.New("failure")
+	// Test
+	p := promise.RaceIndexed(pending(), promise.Reject(failure))
+	// Verify
+	promisetest.AssertRejected(t, p, settleTimeout, failure)
+	promisetest.AssertRejected(t, p, settleTimeout, "promise 1: failure")
+	promisetest.AssertRejected(t, p, settleTimeout, reflect.TypeOf(&promise.IndexedError{}))
+}
+
+func TestRaceContextCancelsLosers(t *testing.T) {
+	// Prepare
+	cancelled := make(chan error, 1)
+	loser := func(ctx context.Context) promise.Promise {
+		return promise.NewPromise(func(resolve func(...interface{}), reject func(error)) {
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			reject(ctx.Err())
+		})
+	}
+	winner := func(ctx context.Context) promise.Promise {
+		return promise.Resolve("winner")
+	}
+	// Test
+	p := promise.RaceContext(context.Background(), loser, winner)
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "winner")
+	promisetest.Eventually(t, func() bool {
+		return len(cancelled) == 1
+	}, settleTimeout)
+	assert.Equal(t, context.Canceled, <-cancelled)
+}
+
+func TestRaceContextRejectsWhenContextIsDone(t *testing.T) {
+	// Prepare
+	ctx, cancel := context.WithCancel(context.Background())
+	never := func(ctx context.Context) promise.Promise {
+		return pending()
+	}
+	// Test
+	p := promise.RaceContext(ctx, never)
+	cancel()
+	// Verify
+	promisetest.AssertRejected(t, p, settleTimeout, context.Canceled)
+}
+
+func TestAllKeepsEveryResult(t *testing.T) {
+	// Test
+	p := promise.All(promise.Resolve("a", 1), promise.Resolve(2), nil, promise.Resolve())
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, []interface{}{promise.Tuple{"a", 1}, 2, nil, nil})
+}
+
+func TestAllWithoutPromisesFulfills(t *testing.T) {
+	// Test
+	p := promise.All()
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, []interface{}{})
+}
+
+func TestAllRejectsWithFirstError(t *testing.T) {
+	// Prepare
+	failure := errors.New("failure")
+	release := make(chan struct{})
+	defer close(release)
+	late := gated(release, func(resolve func(...interface{}), reject func(error)) {
+		resolve(1)
+	})
+	lateFailure := gated(release, func(resolve func(...interface{}), reject func(error)) {
+		reject(errors.New("late"))
+	})
+	// Test
+	p := promise.All(late, promise.Reject(failure), lateFailure)
+	// Verify
+	promisetest.AssertRejected(t, p, settleTimeout, failure)
+}
+
+func TestSpreadPassesAggregateAsArguments(t *testing.T) {
+	// Test
+	p := promise.All(promise.Resolve(1), promise.Resolve("a", true)).
+		Spread(func(value int, pair promise.Tuple) promise.Promise {
+			return promise.Resolve(pair)
+		}).
+		Spread(func(name string, flag bool) (string, error) {
+			return name, nil
+		})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "a")
+}
+
+func TestAllKeepsResultPositions(t *testing.T) {
+	// Prepare
+	release := make(chan struct{})
+	late := gated(release, func(resolve func(...interface{}), reject func(error)) {
+		resolve("first")
+	})
+	// Test
+	p := promise.All(late, promise.Resolve("second"))
+	close(release)
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, []interface{}{"first", "second"})
+}
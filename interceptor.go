@@ -0,0 +1,205 @@
+package promise
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type HandlerKind string
+
+const (
+	KIND_EXECUTOR = HandlerKind("EXECUTOR")
+	KIND_THEN     = HandlerKind("THEN")
+	KIND_CATCH    = HandlerKind("CATCH")
+	KIND_FINALLY  = HandlerKind("FINALLY")
+	KIND_TAP      = HandlerKind("TAP")
+	KIND_SPREAD   = HandlerKind("SPREAD")
+)
+
+// Invocation describes a single handler call. The same *Invocation is passed to Before, Around and After, so
+// interceptors may use it as a key to keep state between them.
+type Invocation struct {
+	Kind    HandlerKind
+	Handler interface{}
+	Promise Promise
+	Params  []interface{}
+
+	// Set once the handler has completed. For executors and other resolve/reject handlers this is when resolve or
+	// reject is called, not when the function returns.
+	Results  []interface{}
+	Err      error
+	Started  time.Time
+	Duration time.Duration
+}
+
+type Interceptor interface {
+	Before(invocation *Invocation)
+	After(invocation *Invocation)
+}
+
+// AroundInterceptor is an Interceptor that also wraps the handler call, to run it with a lock held, inside a tracing
+// span or with the credentials of the caller. Around is called after all Before hooks on the goroutine that runs the
+// handler, and must call next exactly once before returning. The interceptor registered first is the outermost one.
+// A handler that is not run because next was not called rejects its promise with a *ProgrammerError.
+type AroundInterceptor interface {
+	Interceptor
+	Around(invocation *Invocation, next func())
+}
+
+// InterceptorFuncs adapts plain functions to the AroundInterceptor interface. Any of them may be nil.
+type InterceptorFuncs struct {
+	BeforeFunc func(invocation *Invocation)
+	AroundFunc func(invocation *Invocation, next func())
+	AfterFunc  func(invocation *Invocation)
+}
+
+func (interceptor InterceptorFuncs) Before(invocation *Invocation) {
+	if interceptor.BeforeFunc != nil {
+		interceptor.BeforeFunc(invocation)
+	}
+}
+
+func (interceptor InterceptorFuncs) Around(invocation *Invocation, next func()) {
+	if interceptor.AroundFunc != nil {
+		interceptor.AroundFunc(invocation, next)
+	} else {
+		next()
+	}
+}
+
+func (interceptor InterceptorFuncs) After(invocation *Invocation) {
+	if interceptor.AfterFunc != nil {
+		interceptor.AfterFunc(invocation)
+	}
+}
+
+// Interceptors are registered through a pointer, so that removing works for ones that cannot be compared, like
+// InterceptorFuncs.
+type registeredInterceptor struct {
+	Interceptor
+}
+
+var globalInterceptors struct {
+	sync.RWMutex
+	interceptors []*registeredInterceptor
+}
+
+// AddInterceptor registers an interceptor around every handler invocation of every promise. The returned function
+// removes it again.
+func AddInterceptor(interceptor Interceptor) (remove func()) {
+	if interceptor == nil { panic("interceptor cannot be <nil>") }
+
+	globalInterceptors.Lock()
+	defer globalInterceptors.Unlock()
+
+	entry := &registeredInterceptor{interceptor}
+	interceptors := globalInterceptors.interceptors
+	globalInterceptors.interceptors = append(interceptors[:len(interceptors):len(interceptors)], entry)
+	return func() {
+		globalInterceptors.Lock()
+		defer globalInterceptors.Unlock()
+
+		for i, registered := range globalInterceptors.interceptors {
+			if registered == entry {
+				interceptors := append([]*registeredInterceptor(nil), globalInterceptors.interceptors[:i]...)
+				globalInterceptors.interceptors = append(interceptors, globalInterceptors.interceptors[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Intercept registers an interceptor for this promise's handler, unless it has already been invoked, and for the
+// handlers of all promises derived from it afterwards. The executor of a promise returned by NewPromise is scheduled
+// at once, so whether Intercept on that promise sees the EXECUTOR invocation is a race. NewInterceptedPromise
+// registers the interceptors before scheduling it.
+func (this *PromiseProto) Intercept(interceptor Interceptor) Promise {
+	if interceptor == nil { panic("interceptor cannot be <nil>") }
+
+	this.promiseStateLock.Lock()
+	defer this.promiseStateLock.Unlock()
+
+	this.interceptors = append(this.interceptors[:len(this.interceptors):len(this.interceptors)], interceptor)
+	return this
+}
+
+// NewInterceptedPromise is NewPromise with interceptors that are registered before the executor is scheduled, so they
+// always intercept it, as well as the handlers of the promises derived from the returned one.
+func NewInterceptedPromise(callbackFunc interface{}, interceptors ...Interceptor) Promise {
+	promise := newPromise(KIND_EXECUTOR, callbackFunc)
+	for _, interceptor := range interceptors {
+		promise.Intercept(interceptor)
+	}
+	return promise.process()
+}
+
+// Runs the Before hooks of the interceptors that apply to this promise and returns the function that runs the handler
+// through the Around hooks, reporting whether it was run, and the one that completes the invocation. Both are nil when
+// there is nothing to intercept.
+func (this *PromiseProto) intercept(callback *callback, params []reflect.Value) (around func(handler func()) bool,
+	complete func(err error, results []reflect.Value)) {
+	var interceptors []Interceptor
+	globalInterceptors.RLock()
+	for _, registered := range globalInterceptors.interceptors {
+		interceptors = append(interceptors, registered.Interceptor)
+	}
+	globalInterceptors.RUnlock()
+
+	this.promiseStateLock.Lock()
+	interceptors = append(interceptors, this.interceptors...)
+	this.promiseStateLock.Unlock()
+
+	if len(interceptors) == 0 {
+		return nil, nil
+	}
+
+	invocation := &Invocation{
+		Kind:    this.kind,
+		Handler: callback.callback,
+		Promise: this,
+		Params:  valuesToInterfaces(params),
+	}
+	for _, interceptor := range interceptors {
+		interceptor.Before(invocation)
+	}
+	clock := currentClock()
+	invocation.Started = clock.Now()
+
+	around = func(handler func()) bool {
+		var handled int32
+		next := func() {
+			if atomic.CompareAndSwapInt32(&handled, 0, 1) {
+				handler()
+			}
+		}
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			if aroundInterceptor, isAround := interceptors[i].(AroundInterceptor); isAround {
+				inner := next
+				next = func() { aroundInterceptor.Around(invocation, inner) }
+			}
+		}
+		next()
+		return atomic.LoadInt32(&handled) == 1
+	}
+	complete = func(err error, results []reflect.Value) {
+		invocation.Duration = clock.Now().Sub(invocation.Started)
+		invocation.Results, invocation.Err = valuesToInterfaces(results), err
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptors[i].After(invocation)
+		}
+	}
+	return
+}
+
+func valuesToInterfaces(values []reflect.Value) (interfaces []interface{}) {
+	for _, value := range values {
+		if value.IsValid() {
+			interfaces = append(interfaces, value.Interface())
+		} else {
+			interfaces = append(interfaces, nil)
+		}
+	}
+	return
+}
@@ -0,0 +1,193 @@
+package promise_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"testing"
+)
+
+func TestInterceptChain(t *testing.T) {
+	// Prepare
+	invocations := make(chan *promise.Invocation, 3)
+	interceptor := promise.InterceptorFuncs{AfterFunc: func(invocation *promise.Invocation) {
+		if invocation.Kind != promise.KIND_EXECUTOR {
+			invocations <- invocation
+		}
+	}}
+	// Test
+	p := promise.Resolve(1).
+		Intercept(interceptor).
+		Then(func(value int) (int, error) {
+			return value + 1, nil
+		}).
+		Then(func(value int) error {
+			return errors.New("failed")
+		}).
+		Catch(func(err error) {
+		})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout)
+	for _, expected := range []struct {
+		kind    promise.HandlerKind
+		params  []interface{}
+		results []interface{}
+		err     string
+	}{
+		{promise.KIND_THEN, []interface{}{1}, []interface{}{2}, ""},
+		{promise.KIND_THEN, []interface{}{2}, nil, "failed"},
+		{promise.KIND_CATCH, []interface{}{errors.New("failed")}, nil, ""},
+	} {
+		var invocation *promise.Invocation
+		select {
+		case invocation = <- invocations:
+		default:
+			t.Errorf("missing %s invocation", expected.kind)
+			return
+		}
+		assert.Equal(t, expected.kind, invocation.Kind)
+		assert.Equal(t, expected.params, invocation.Params)
+		assert.Equal(t, expected.results, invocation.Results)
+		if expected.err != "" {
+			assert.EqualError(t, invocation.Err, expected.err)
+		} else {
+			assert.NoError(t, invocation.Err)
+		}
+	}
+}
+
+func TestAddInterceptor(t *testing.T) {
+	// Prepare
+	type marker struct{}
+	recorder := promisetest.NewRecorder()
+	remove := promise.AddInterceptor(promise.InterceptorFuncs{
+		BeforeFunc: func(invocation *promise.Invocation) {
+			if len(invocation.Params) == 1 && invocation.Params[0] == (marker{}) {
+				recorder.Record("before")
+			}
+		},
+		AfterFunc: func(invocation *promise.Invocation) {
+			if len(invocation.Params) == 1 && invocation.Params[0] == (marker{}) {
+				recorder.Record("after")
+			}
+		},
+	})
+	defer remove()
+	// Test
+	promise.Resolve(marker{}).Then(func(value marker) {
+		recorder.Record("handler")
+	})
+	// Verify
+	recorder.AssertEvents(t, settleTimeout, "before", "handler", "after")
+}
+
+func TestAroundWrapsHandler(t *testing.T) {
+	// Prepare
+	recorder := promisetest.NewRecorder()
+	record := func(event string) func(invocation *promise.Invocation) {
+		return func(invocation *promise.Invocation) {
+			if invocation.Kind == promise.KIND_THEN {
+				recorder.Record(event)
+			}
+		}
+	}
+	interceptor := promise.InterceptorFuncs{
+		BeforeFunc: record("before"),
+		AroundFunc: func(invocation *promise.Invocation, next func()) {
+			record("around")(invocation)
+			next()
+			record("around done")(invocation)
+		},
+		AfterFunc: record("after"),
+	}
+	// Test
+	p := promise.Resolve(1).
+		Intercept(interceptor).
+		Then(func(value int) (int, error) {
+			recorder.Record("handler")
+			return value, nil
+		}).
+		Then(func(value int, resolve func(...interface{}), reject func(error)) {
+			recorder.Record("resolve handler")
+			resolve(value)
+		})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, 1)
+	// A resolve/reject handler completes when it calls resolve, so its After comes first.
+	recorder.AssertEvents(t, settleTimeout,
+		"before", "around", "handler", "around done", "after",
+		"before", "around", "resolve handler", "after", "around done")
+}
+
+func TestAroundNestsInRegistrationOrder(t *testing.T) {
+	// Prepare
+	recorder := promisetest.NewRecorder()
+	around := func(name string) promise.Interceptor {
+		return promise.InterceptorFuncs{AroundFunc: func(invocation *promise.Invocation, next func()) {
+			if invocation.Kind == promise.KIND_THEN {
+				recorder.Record(name)
+			}
+			next()
+		}}
+	}
+	// Test
+	p := promise.Resolve(1).
+		Intercept(around("outer")).
+		Intercept(around("inner")).
+		Then(func(value int) {
+			recorder.Record("handler")
+		})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout)
+	recorder.AssertEvents(t, settleTimeout, "outer", "inner", "handler")
+}
+
+func TestAroundWithoutNextRejects(t *testing.T) {
+	// Prepare
+	interceptor := promise.InterceptorFuncs{AroundFunc: func(invocation *promise.Invocation, next func()) {
+		if invocation.Kind != promise.KIND_THEN {
+			next()
+		}
+	}}
+	handled := make(chan struct{}, 1)
+	// Test
+	p := promise.Resolve(1).
+		Intercept(interceptor).
+		Then(func(value int) {
+			handled <- struct{}{}
+		})
+	// Verify
+	promisetest.AssertRejected(t, p, settleTimeout, func(err error) bool {
+		return promise.IsProgrammerError(err) && err.Error() == "an interceptor did not call next"
+	})
+	assert.Empty(t, handled)
+}
+
+func TestAssertionsAreNotIntercepted(t *testing.T) {
+	// Prepare
+	recorder := promisetest.NewRecorder()
+	source := promise.Resolve(1)
+	promisetest.AssertFulfilled(t, source, settleTimeout)
+	// Test
+	p := source.Intercept(recorder).Then(func(value int) (int, error) {
+		return value, nil
+	})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, 1)
+	assert.Equal(t, []string{"THEN"}, recorder.Events())
+}
+
+func TestNewInterceptedPromiseInterceptsExecutor(t *testing.T) {
+	// Prepare
+	recorder := promisetest.NewRecorder()
+	// Test
+	p := promise.NewInterceptedPromise(func(resolve func(...interface{}), reject func(error)) {
+		resolve(1)
+	}, recorder).Then(func(value int) (int, error) {
+		return value, nil
+	})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, 1)
+	assert.Equal(t, []string{"EXECUTOR", "THEN"}, recorder.Events())
+}
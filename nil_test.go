@@ -0,0 +1,93 @@
+package promise_test
+
+import (
+	"errors"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"testing"
+)
+
+type nilValue struct{}
+
+func TestNilResultsReachHandlersAsZeroValues(t *testing.T) {
+	for name, source := range map[string]func() promise.Promise{
+		"resolve": func() promise.Promise {
+			return promise.Resolve(nil)
+		},
+		"nil interface": func() promise.Promise {
+			return promise.Resolve(1).Then(func(value int) (interface{}, error) {
+				return nil, nil
+			})
+		},
+		"nil promise": func() promise.Promise {
+			return promise.Resolve(1).Then(func(value int) promise.Promise {
+				return nil
+			})
+		},
+		"nil promise and error": func() promise.Promise {
+			return promise.Resolve(1).Then(func(value int) (promise.Promise, error) {
+				return nil, nil
+			})
+		},
+		"typed nil promise": func() promise.Promise {
+			return promise.Resolve(1).Then(func(value int) promise.Promise {
+				return (*promise.PromiseProto)(nil)
+			})
+		},
+		"catch": func() promise.Promise {
+			return promise.Reject(errors.New("rejected")).Catch(func(err error) (interface{}, error) {
+				return nil, nil
+			})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Test
+			p := source()
+			asInterface := p.Then(func(value interface{}) (interface{}, error) {
+				return value, nil
+			})
+			asString := p.Then(func(value string) (string, error) {
+				return value, nil
+			})
+			asPointer := p.Then(func(value *nilValue) (*nilValue, error) {
+				return value, nil
+			})
+			// Verify
+			promisetest.AssertFulfilled(t, p, settleTimeout, nil)
+			promisetest.AssertFulfilled(t, asInterface, settleTimeout, nil)
+			promisetest.AssertFulfilled(t, asString, settleTimeout, "")
+			promisetest.AssertFulfilled(t, asPointer, settleTimeout, (*nilValue)(nil))
+		})
+	}
+}
+
+func TestTypedNilResult(t *testing.T) {
+	// Test
+	p := promise.Resolve((*nilValue)(nil))
+	asInterface := p.Then(func(value interface{}) (interface{}, error) {
+		return value, nil
+	})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, (*nilValue)(nil))
+	promisetest.AssertFulfilled(t, asInterface, settleTimeout, (*nilValue)(nil))
+}
+
+func TestNilResultWithResolveRejectHandler(t *testing.T) {
+	// Test
+	p := promise.Resolve(nil).Then(func(value *nilValue, resolve func(...interface{}), reject func(error)) {
+		resolve(value, nil)
+	})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, (*nilValue)(nil), nil)
+}
+
+func TestRejectWithNilError(t *testing.T) {
+	// Test
+	p := promise.NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		reject(nil)
+	})
+	// Verify
+	promisetest.AssertRejected(t, p, settleTimeout, func(err error) bool {
+		return promise.IsProgrammerError(err) && err.Error() == "promise rejected with a <nil> error"
+	})
+}
@@ -0,0 +1,40 @@
+package promise
+
+// Progress is the progress value reported by combinators: Settled out of Total input promises have settled.
+type Progress struct {
+	Settled int
+	Total   int
+}
+
+// OnProgress registers a handler for the progress values reported while the promise is pending. Progress reported by
+// an executor taking a progress func(interface{}) argument travels down the chain to the derived promises. Handlers
+// registered after the promise has settled are never called.
+func (this *PromiseProto) OnProgress(handler func(interface{})) Promise {
+	if handler == nil { panic("progress handler cannot be <nil>") }
+
+	this.promiseStateLock.Lock()
+	defer this.promiseStateLock.Unlock()
+
+	if this.State() == STATE_PENDING {
+		this.progressListeners = append(this.progressListeners, handler)
+	}
+	return this
+}
+
+// Progress values go through the same queue as the state listeners, so progress handlers run one at a time, in the
+// order the values were reported, and always before the handlers of the settled promise.
+func (this *PromiseProto) notifyProgress(value interface{}) {
+	this.promiseStateLock.Lock()
+
+	if this.State() != STATE_PENDING {
+		this.promiseStateLock.Unlock()
+		return
+	}
+	for _, progressListener := range this.progressListeners {
+		progressListener := progressListener
+		this.dispatchQueue = append(this.dispatchQueue, func(callbackState) {
+			progressListener(value)
+		})
+	}
+	this.startDispatch()
+}
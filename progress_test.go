@@ -0,0 +1,46 @@
+package promise_test
+
+import (
+	"fmt"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"testing"
+)
+
+func TestOnProgressThroughChain(t *testing.T) {
+	// Prepare
+	start := make(chan struct{})
+	recorder := promisetest.NewRecorder()
+	// Test
+	p := promise.NewPromise(func(resolve func(...interface{}), reject func(error), progress func(interface{})) {
+		<-start
+		progress(1)
+		progress(2)
+		resolve("done")
+	}).Then(func(value string) {
+	}).OnProgress(func(value interface{}) {
+		recorder.Record(fmt.Sprint(value))
+	})
+	close(start)
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout)
+	recorder.AssertEvents(t, settleTimeout, "1", "2")
+}
+
+func TestAllReportsProgress(t *testing.T) {
+	// Prepare
+	releases := []chan struct{}{make(chan struct{}), make(chan struct{})}
+	recorder := promisetest.NewRecorder()
+	// Test
+	promise.All(
+		gated(releases[0], func(resolve func(...interface{}), reject func(error)) { resolve(0) }),
+		gated(releases[1], func(resolve func(...interface{}), reject func(error)) { resolve(0) }),
+	).OnProgress(func(value interface{}) {
+		recorder.Record(fmt.Sprint(value))
+	})
+	// Verify
+	close(releases[0])
+	recorder.AssertEvents(t, settleTimeout, fmt.Sprint(promise.Progress{Settled: 1, Total: 2}))
+	close(releases[1])
+	recorder.AssertEvents(t, settleTimeout, fmt.Sprint(promise.Progress{Settled: 1, Total: 2}), fmt.Sprint(promise.Progress{Settled: 2, Total: 2}))
+}
@@ -1,194 +1,286 @@
 package promise
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 )
 
 type Promise interface {
+	Thenable
+
 	Then(resolver interface{}, rejector ...interface{}) Promise
 	Tap(callback interface{}) Promise
 	Spread(callback interface{}) Promise
 	Catch(handler interface{}) Promise
+	CatchIf(predicateOrTypes ...interface{}) Promise
 	Finally(handler interface{}) Promise
-
-	setState(state callbackState)
-	State() callbackState
-	setResults([]reflect.Value)
-	results() []reflect.Value
+	ThenE(resolver interface{}, rejector ...interface{}) (Promise, error)
+	TapE(callback interface{}) (Promise, error)
+	SpreadE(callback interface{}) (Promise, error)
+	CatchE(handler interface{}) (Promise, error)
+	CatchIfE(predicateOrTypes ...interface{}) (Promise, error)
+	FinallyE(handler interface{}) (Promise, error)
+	OnProgress(handler func(interface{})) Promise
+	Intercept(interceptor Interceptor) Promise
+	Named(label string) Promise
+	Label() string
+
+	State() PromiseState
 	Results() []interface{}
-	setError(error)
 	Error() (err error)
+	Scan(ctx context.Context, dest ...interface{}) error
+	ScanStruct(ctx context.Context, dest interface{}) error
 
-	addStateCompleteListener(listener func(state callbackState)) Promise
-	call(paramValues ...reflect.Value)
-	process(parameters ...interface{}) Promise
-
-	this(func (Promise)) Promise
 	This(func (Promise)) Promise
 }
 
+// The outcome of a promise. It is published at once, so the state can never be observed without its results or error.
+type settlement struct {
+	state   callbackState
+	results []reflect.Value
+	err     error
+}
+
 type PromiseProto struct {
 	callback *callback
+	kind     HandlerKind
+	label    string
 
-	callbackError error
-	callbackResults []reflect.Value
-	promiseState         callbackState
+	promiseSettlement    atomic.Value
 	promiseStateLock     sync.Mutex
 	stateChangeListeners []func(state callbackState)
-
-	mux           sync.Mutex
+	dispatchQueue        []func(state callbackState)
+	dispatching          bool
+	progressListeners    []func(value interface{})
+	interceptors         []Interceptor
+	awaiting             Promise
+	awaitingLock         sync.Mutex
 }
 
-func (this *PromiseProto) this(closure func(this Promise)) Promise {
+func (this *PromiseProto) this(closure func(this *PromiseProto)) *PromiseProto {
 	closure(this)
 	return this
 }
 
 func (this *PromiseProto) This(closure func(this Promise)) Promise {
-	return this.this(closure)
+	closure(this)
+	return this
 }
 
 func NewPromise(callbackFunc interface{}) Promise {
-	return newPromise(callbackFunc).process()
+	return newPromise(KIND_EXECUTOR, callbackFunc).process()
 }
 
-func newPromise(callbackFunc interface{}) *PromiseProto {
-	if !IsFunc(callbackFunc) || !isValidCallbackSignature(callbackFunc) {
-		panic("Invalid callback signature")
+func newPromise(kind HandlerKind, callbackFunc interface{}) *PromiseProto {
+	if !isValidCallbackSignature(callbackFunc) {
+		panic(Programmer(fmt.Errorf("invalid callback signature: %T", handlerFunc(callbackFunc))))
 	}
 
-	return new(PromiseProto).this(func(this Promise) {
-		this.(*PromiseProto).callback = newCallback(callbackFunc)
-	}).(*PromiseProto)
+	return newPromiseProto(kind).this(func(this *PromiseProto) {
+		this.callback = newCallback(callbackFunc)
+	})
+}
+
+// Sets up a promise derived from this one: the progress of this promise is forwarded to it and it inherits the
+// interceptors registered on the chain.
+func (this *PromiseProto) derive(derived *PromiseProto) {
+	this.promiseStateLock.Lock()
+	derived.interceptors = this.interceptors
+	this.promiseStateLock.Unlock()
+
+	derived.setAwaiting(this)
+	trackParent(derived, this)
+
+	this.OnProgress(derived.notifyProgress)
 }
 
 func (this *PromiseProto) call(paramValues ...reflect.Value) {
-	this.callback.call(paramValues...)(func(err error, results ...reflect.Value) {
+	this.run(this.callback, paramValues, this.settle)
+}
+
+// Calls callback with paramValues and passes its outcome to complete, once a returned promise has been adopted.
+func (this *PromiseProto) run(callback *callback, paramValues []reflect.Value,
+	complete func(state callbackState, results []reflect.Value, err error) bool) {
+	this.setAwaiting(nil)
+	around, intercepted := this.intercept(callback, paramValues)
+	callback.call(this.notifyProgress, around, paramValues...)(func(err error, results ...reflect.Value) {
+		if intercepted != nil {
+			intercepted(err, results)
+		}
+
 		if err != nil {
-			this.setError(err)
-			this.setState(STATE_REJECTED)
+			complete(STATE_REJECTED, nil, err)
 			return
 		}
 
-		if this.callback.isReturningPromise && len(results) > 0 {
-			if promiseValue := results[0]; promiseValue.Type().Implements(PROMISE_TYPE) {
-				promise, _ := promiseValue.Interface().(Promise)
-				if promise != nil {
-					promise.addStateCompleteListener(func(state callbackState) {
-						switch state {
-						case STATE_FULFILLED:
-							this.setResults(promise.results())
-							this.setState(state)
-						case STATE_REJECTED:
-							this.setError(promise.Error())
-							this.setState(state)
-						}
-					})
-				} else {
-					// TODO : panic?
-				}
+		if callback.isReturningPromise && len(results) > 0 {
+			if promise, isPromise := asThenable(results[:1]); isPromise {
+				this.adopt(promise, complete)
+			} else {
+				// A nil promise has nothing to wait for, it stands for a nil value.
+				complete(STATE_FULFILLED, []reflect.Value{{}}, nil)
 			}
+		} else if thenable, isThenable := asThenable(results); isThenable {
+			this.adopt(thenable, complete)
 		} else {
-			this.setResults(results)
-			this.setState(STATE_FULFILLED)
+			complete(STATE_FULFILLED, results, nil)
 		}
 	})
 }
 
 func (this *PromiseProto) process(parameters ...interface{}) Promise {
-	go func() {
+	currentScheduler().Schedule(func() {
 		var paramValues []reflect.Value
 		for _, parameter := range parameters {
 			paramValues = append(paramValues, reflect.ValueOf(parameter))
 		}
 
 		this.call(paramValues...)
-	}()
+	})
 	return this
 }
 
-func (this *PromiseProto) setState(state callbackState) {
+// Moves the promise out of STATE_PENDING. Only the first call has an effect, the ones after it report false.
+func (this *PromiseProto) settle(state callbackState, results []reflect.Value, err error) bool {
 	this.promiseStateLock.Lock()
-	defer this.promiseStateLock.Unlock()
 
-	if this.State() == STATE_PENDING {
-		this.promiseState = state
-		this.fireStateChanged(state)
-		this.stateChangeListeners = nil
-	} else {
+	if this.settlement() != nil {
+		this.promiseStateLock.Unlock()
 		// TODO: print or log something out
+		return false
 	}
+
+	this.promiseSettlement.Store(&settlement{state, results, err})
+	this.setAwaiting(nil)
+	untrack(this)
+	this.dispatchQueue = append(this.dispatchQueue, this.stateChangeListeners...)
+	this.stateChangeListeners = nil
+	this.progressListeners = nil
+	this.startDispatch()
+	return true
+}
+
+// Returns nil while the promise is pending.
+func (this *PromiseProto) settlement() *settlement {
+	settled, _ := this.promiseSettlement.Load().(*settlement)
+	return settled
+}
+
+// Named labels the promise in the DumpPending output.
+func (this *PromiseProto) Named(label string) Promise {
+	this.promiseStateLock.Lock()
+	defer this.promiseStateLock.Unlock()
+
+	this.label = label
+	return this
+}
+
+func (this *PromiseProto) Label() string {
+	this.promiseStateLock.Lock()
+	defer this.promiseStateLock.Unlock()
+
+	return this.label
 }
 
 func (this *PromiseProto) State() callbackState {
-	if this.promiseState == "" {
-		return STATE_PENDING
+	if settled := this.settlement(); settled != nil {
+		return settled.state
 	}
-	return this.promiseState
+	return STATE_PENDING
 }
 
 func (this *PromiseProto) addStateCompleteListener(listener func(state callbackState)) Promise {
 	if listener == nil { panic("callback state listener cannot be <nil>") }
 
 	this.promiseStateLock.Lock()
-	defer this.promiseStateLock.Unlock()
 
 	switch this.State() {
 	case STATE_PENDING:
 		this.stateChangeListeners = append(this.stateChangeListeners, listener)
+		this.promiseStateLock.Unlock()
 	case STATE_FULFILLED, STATE_REJECTED:
-		listener(this.State())
+		this.dispatchQueue = append(this.dispatchQueue, listener)
+		this.startDispatch()
 	default:
+		this.promiseStateLock.Unlock()
 		panic("Invalid callback state: " + this.State())
 	}
 	return this
 }
 
+// Listeners never run under promiseStateLock, so they are free to use the promise they listen to. They are queued and
+// run one after another, in the order they were registered, by a single task of the scheduler at a time. Unless
+// promises run synchronously, that task is never the goroutine that settles the promise or registers the listener.
+// Must be called with promiseStateLock held, which it releases before scheduling the dispatch.
+func (this *PromiseProto) startDispatch() {
+	start := !this.dispatching && len(this.dispatchQueue) > 0
+	this.dispatching = this.dispatching || start
+	this.promiseStateLock.Unlock()
+
+	if start {
+		currentScheduler().Schedule(this.dispatch)
+	}
+}
+
+func (this *PromiseProto) dispatch() {
+	for {
+		this.promiseStateLock.Lock()
+		if len(this.dispatchQueue) == 0 {
+			this.dispatching = false
+			this.promiseStateLock.Unlock()
+			return
+		}
+		listener := this.dispatchQueue[0]
+		this.dispatchQueue = this.dispatchQueue[1:]
+		this.promiseStateLock.Unlock()
+
+		listener(this.State())
+	}
+}
+
+// Then follows Promises/A+: either handler may be nil, in which case the outcome of this promise passes through to the
+// returned one. A rejector taking a specific error type only handles rejections matching it and one taking an error
+// skips *ProgrammerErrors, like Catch does.
 func (this *PromiseProto) Then(resolver interface{}, rejector ...interface{}) Promise {
-	return new(PromiseProto).this(func(promise Promise) {
-		newPromise := promise.(*PromiseProto)
-		switch len(rejector) {
-		case 1:
-			rejector := rejector[0]
-			assertFunctionSignature(rejector, _FUNC_IN_ERROR_OUT)
-			assertFunctionSignature(resolver, _FUNC_IN_VARIADIC_OBJS_OUT)
-
-			this.addStateCompleteListener(func(state callbackState) {
-				switch state {
-				case STATE_FULFILLED:
-					newPromise.callback = newCallback(resolver)
+	if len(rejector) > 1 { panic(Programmer(errors.New("only one rejector can be defined"))) }
+
+	var resolverCallback, rejectorCallback *callback
+	if resolver != nil {
+		assertFunctionSignature(resolver, _THEN_SIGNATURES...)
+		resolverCallback = newCallback(resolver)
+	}
+	if len(rejector) == 1 && rejector[0] != nil {
+		assertFunctionSignature(rejector[0], _CATCH_SIGNATURES...)
+		rejectorCallback = newCallback(rejector[0])
+	}
+
+	return newPromiseProto(KIND_THEN).this(func(newPromise *PromiseProto) {
+		newPromise.callback = resolverCallback
+		this.derive(newPromise)
+
+		this.addStateCompleteListener(func(state callbackState) {
+			switch state {
+			case STATE_FULFILLED:
+				if newPromise.callback != nil {
 					newPromise.call(this.results()...)
-				case STATE_REJECTED:
-					newPromise.callback = newCallback(rejector)
-					newPromise.call(reflect.ValueOf(this.Error()))
+					return
 				}
-			})
-		case 0:
-			assertFunctionSignature(resolver,
-				_FUNC_IN_OBJS_RESOLVE_OBJS_REJECT_ERROR_OUT,
-				_FUNC_IN_OUT,
-				_FUNC_IN_VARIADIC_OBJS_OUT,
-				_FUNC_IN_VARIADIC_OBJS_OUT_ERROR,
-				_FUNC_IN_VARIADIC_OBJS_OUT_OBJ_ERROR,
-				_FUNC_IN_VARIADIC_OBJS_OUT_OBJS_ERROR,
-				_FUNC_IN_VARIADIC_OBJS_OUT_PROMISE,
-				_FUNC_IN_VARIADIC_OBJS_OUT_PROMISE_ERROR,
-			)
-
-			this.addStateCompleteListener(func(state callbackState) {
-				switch state {
-				case STATE_FULFILLED:
-					newPromise.callback = newCallback(resolver)
-					newPromise.call(this.results()...)
-				case STATE_REJECTED:
-					newPromise.callback = this.callback
-					newPromise.process(this.Error())
+			case STATE_REJECTED:
+				if rejectorCallback != nil {
+					errorType := reflect.TypeOf(rejectorCallback.callback).In(0)
+					if errorValue, matches := matchError(this.Error(), errorType); matches &&
+						(errorType != ERROR_TYPE || !IsProgrammerError(this.Error())) {
+						newPromise.run(rejectorCallback, []reflect.Value{errorValue}, newPromise.settle)
+						return
+					}
 				}
-			})
-		default:
-			panic("Only one rejector can be defined")
-		}
+			}
+			newPromise.settle(state, this.results(), this.Error())
+		})
 	})
 }
 
@@ -198,21 +290,19 @@ func (this *PromiseProto) Tap(callback interface{}) Promise {
 		_FUNC_IN_VARIADIC_OBJS_OUT,
 		_FUNC_IN_VARIADIC_OBJS_OUT_ERROR,
 	)
-	return newPromise(callback).this(func(promise Promise) {
-		newPromise := promise.(*PromiseProto)
+	return newPromise(KIND_TAP, callback).this(func(newPromise *PromiseProto) {
+		this.derive(newPromise)
 		this.addStateCompleteListener(func(state callbackState) {
 			switch state {
 			case STATE_FULFILLED:
-				newPromise.call(this.results()...)
+				newPromise.run(newPromise.callback, this.results(), func(state callbackState, results []reflect.Value, err error) bool {
+					if state == STATE_REJECTED {
+						return newPromise.settle(state, nil, err)
+					}
+					return newPromise.settle(STATE_FULFILLED, this.results(), nil)
+				})
 			case STATE_REJECTED:
-				newPromise.setError(this.Error())
-				newPromise.setState(state)
-			}
-		})
-		newPromise.addStateCompleteListener(func(state callbackState) {
-			if newPromise.Error() == nil {
-				newPromise.setError(this.Error())
-				newPromise.setResults(this.results())
+				newPromise.settle(state, nil, this.Error())
 			}
 		})
 	})
@@ -229,85 +319,83 @@ func (this *PromiseProto) Spread(callback interface{}) Promise {
 		_FUNC_IN_VARIADIC_OBJS_OUT_PROMISE_ERROR,
 	)
 
-	return newPromise(callback).this(func(newPromise Promise) {
+	return newPromise(KIND_SPREAD, callback).this(func(newPromise *PromiseProto) {
+		this.derive(newPromise)
 		this.addStateCompleteListener(func(state callbackState) {
 			switch state {
 			case STATE_FULFILLED:
-				newPromise.call(this.results()...)
+				newPromise.call(spreadResults(this.results())...)
 			case STATE_REJECTED:
-				newPromise.setError(this.Error())
-				newPromise.setState(state)
+				newPromise.settle(state, nil, this.Error())
 			}
 		})
 	})
 }
 
+// A single []interface{} result, like the aggregate of All or a Tuple, is passed to a Spread handler element by element.
+func spreadResults(results []reflect.Value) []reflect.Value {
+	if len(results) != 1 || !results[0].IsValid() || results[0].Kind() != reflect.Slice ||
+		results[0].Type().Elem() != INTERFACES_TYPE.Elem() {
+		return results
+	}
+	spread := make([]reflect.Value, results[0].Len())
+	for i := range spread {
+		spread[i] = reflect.ValueOf(results[0].Index(i).Interface())
+	}
+	return spread
+}
 
 
-func (this *PromiseProto) setError(err error) {
-	this.callbackError = err
-}
 
 func (this *PromiseProto) Error() (err error) {
-	if this.callback == nil {
-		return nil
+	if settled := this.settlement(); settled != nil {
+		return settled.err
 	}
-	return this.callbackError
-}
-
-func (this *PromiseProto) setResults(results []reflect.Value) {
-	this.callbackResults = results
+	return nil
 }
 
 func (this *PromiseProto) results() (results []reflect.Value) {
-	if this.callback != nil && this.callbackResults != nil {
-		results = this.callbackResults
+	if settled := this.settlement(); settled != nil {
+		return settled.results
 	}
-	return
+	return nil
 }
 
 func (this *PromiseProto) Results() (results []interface{}) {
-	if this.callback != nil && this.results() != nil {
-		for _, result := range this.results() {
-			results = append(results, result.Interface())
-		}
-	}
-	return
+	return valuesToInterfaces(this.results())
 }
 
 func (this *PromiseProto) Catch(handler interface{}) Promise {
-	var errorType reflect.Type
+	return this.catch(nil, handler)
+}
 
-	assertFunctionSignature(handler,
-		_FUNC_IN_ERROR_RESOLVE_OBJS_REJECT_ERROR_OUT,	// func(error, resolve func(...interface{}), reject func(error))
-		_FUNC_IN_ERROR_OUT, 							// func(error)
-		_FUNC_IN_ERROR_OUT_ERROR, 						// func(error) (error)
-		_FUNC_IN_ERROR_OUT_OBJ_ERROR, 					// func(error) (interface{}, error)
-		_FUNC_IN_ERROR_OUT_OBJS_ERROR, 					// func(error) ([]interface{}, error)
-		_FUNC_IN_ERROR_OUT_PROMISE, 					// func(error) (*Promise)
-		_FUNC_IN_ERROR_OUT_PROMISE_ERROR, 				// func(error) (*Promise, error)
-	)
+// CatchIf follows Bluebird's filtered catch: the last argument is the handler, the ones before it are predicates that
+// the rejection reason must satisfy. See newErrorFilter for the supported predicates.
+func (this *PromiseProto) CatchIf(predicateOrTypes ...interface{}) Promise {
+	if len(predicateOrTypes) < 2 { panic(Programmer(errors.New("CatchIf requires at least one predicate and a handler"))) }
+
+	handler := predicateOrTypes[len(predicateOrTypes) - 1]
+	return this.catch(newErrorFilter(predicateOrTypes[:len(predicateOrTypes) - 1]...), handler)
+}
+
+func (this *PromiseProto) catch(filter errorFilter, handler interface{}) Promise {
+	assertFunctionSignature(handler, _CATCH_SIGNATURES...)
 
-	handlerType := reflect.TypeOf(handler)
-	errorType = handlerType.In(0)
+	errorType := reflect.TypeOf(handlerFunc(handler)).In(0)
 
-	return newPromise(handler).this(func(newPromise Promise) {
+	return newPromise(KIND_CATCH, handler).this(func(newPromise *PromiseProto) {
+		this.derive(newPromise)
 		this.addStateCompleteListener(func(state callbackState) {
 			switch state {
 			case STATE_REJECTED:
-				if typedError, isTyped := this.Error().(typedError);
-					(isTyped && typedError.IsTypeOf(errorType)) ||
-						func(typ reflect.Type) bool {
-							return typ == errorType || typ.Implements(ERROR_TYPE)
-						}(reflect.TypeOf(this.Error())) {
-					newPromise.process(this.Error())
+				if errorValue, matches := matchError(this.Error(), errorType); matches && filter.matches(this.Error()) &&
+					(len(filter) > 0 || errorType != ERROR_TYPE || !IsProgrammerError(this.Error())) {
+					newPromise.call(errorValue)
 				} else {
-					newPromise.setError(this.Error())
-					newPromise.setState(state)
+					newPromise.settle(state, nil, this.Error())
 				}
 			case STATE_FULFILLED:
-				newPromise.setResults(this.results())
-				newPromise.setState(state)
+				newPromise.settle(state, this.results(), nil)
 			}
 		})
 	})
@@ -323,45 +411,21 @@ func (this *PromiseProto) Finally(handler interface{}) Promise {
 		_FUNC_IN_OUT_PROMISE_ERROR,
 	)
 
-	return newPromise(handler).this(func(newPromise Promise) {
+	return newPromise(KIND_FINALLY, handler).this(func(newPromise *PromiseProto) {
+		this.derive(newPromise)
 		this.addStateCompleteListener(func(state callbackState) {
-			switch state {
-			case STATE_REJECTED, STATE_FULFILLED:
-				newPromise.addStateCompleteListener(func(state callbackState) {
-					if state != STATE_REJECTED {
-						newPromise.setError(this.Error())
-						newPromise.setResults(this.results())
-					}
-				}).process()
-			}
+			newPromise.run(newPromise.callback, nil, func(handlerState callbackState, results []reflect.Value, err error) bool {
+				if handlerState == STATE_REJECTED {
+					return newPromise.settle(handlerState, nil, err)
+				}
+				return newPromise.settle(state, this.results(), this.Error())
+			})
 		})
 	})
 }
 
 
-func (this *PromiseProto) fireStateChanged(state callbackState) {
-	stateChangeListeners := this.stateChangeListeners
-	for _, stateChangeListener := range stateChangeListeners {
-		stateChangeListener(state)
-	}
-}
-
 func IsFunc(target interface{}) (isFunc bool) {
 	defer func() { if r := recover(); r != nil { isFunc = false } }()
 	return reflect.TypeOf(target).Kind() == reflect.Func
 }
-
-func isChanOpen(ch chan interface{}) bool {
-	select {
-	case result, open := <- ch:
-		if !open { return false }
-		ch <- result
-	default:
-	}
-	return true
-}
-
-func isChanClosed(ch chan interface{}) bool {
-	return !isChanOpen(ch)
-}
-
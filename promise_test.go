@@ -196,4 +196,4 @@ func TestNewPromiseRejectCatchThenString(t *testing.T) {
 			return
 		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,145 @@
+// Package promisetest provides assertions and helpers for testing code built on go-bird promises.
+//
+// Waiting is always done on the real time, so the helpers keep working when a FakeClock is installed with
+// promise.SetClock.
+package promisetest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"reflect"
+	"time"
+)
+
+// TestingT is the subset of testing.TB used by the helpers.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+type tHelper interface {
+	Helper()
+}
+
+// Await waits until p is settled and reports whether it settled within timeout. It subscribes to p rather than
+// chaining a handler, so no promise is derived from p and interceptors of p see nothing of the wait.
+func Await(p promise.Thenable, timeout time.Duration) bool {
+	settled := make(chan struct{})
+	p.Subscribe(func(...interface{}) {
+		close(settled)
+	}, func(error) {
+		close(settled)
+	})
+
+	select {
+	case <-settled:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// AssertFulfilled asserts that p fulfills within timeout. If any expected values are given, the results of p must
+// equal them.
+func AssertFulfilled(t TestingT, p promise.Promise, timeout time.Duration, expected ...interface{}) bool {
+	if h, ok := t.(tHelper); ok { h.Helper() }
+
+	if !Await(p, timeout) {
+		t.Errorf("promise %s still pending after %s", describe(p), timeout)
+		return false
+	}
+	if p.State() != promise.STATE_FULFILLED {
+		t.Errorf("expected promise %s to be fulfilled, but it was rejected with: %v", describe(p), p.Error())
+		return false
+	}
+	if len(expected) > 0 {
+		return assert.Equal(t, expected, p.Results(), "results of promise %s", describe(p))
+	}
+	return true
+}
+
+// AssertRejected asserts that p rejects within timeout with an error accepted by matcher, which is one of:
+//   - nil, accepting any error
+//   - an error, matched with errors.Is
+//   - a reflect.Type of an error type, matched with errors.As
+//   - a string, compared with the error message
+//   - a func(error) bool
+func AssertRejected(t TestingT, p promise.Promise, timeout time.Duration, matcher interface{}) bool {
+	if h, ok := t.(tHelper); ok { h.Helper() }
+
+	matches := errorMatcher(matcher)
+	if !Await(p, timeout) {
+		t.Errorf("promise %s still pending after %s", describe(p), timeout)
+		return false
+	}
+	if p.State() != promise.STATE_REJECTED {
+		t.Errorf("expected promise %s to be rejected, but it was fulfilled with: %v", describe(p), p.Results())
+		return false
+	}
+	if !matches(p.Error()) {
+		t.Errorf("promise %s was rejected with an error not matching %v: %v", describe(p), matcher, p.Error())
+		return false
+	}
+	return true
+}
+
+// AssertPending asserts that p is still pending after waiting for d. A zero d checks the current state only.
+func AssertPending(t TestingT, p promise.Promise, d time.Duration) bool {
+	if h, ok := t.(tHelper); ok { h.Helper() }
+
+	if d > 0 && Await(p, d) || p.State() != promise.STATE_PENDING {
+		t.Errorf("expected promise %s to be pending, but it was %s", describe(p), describeOutcome(p))
+		return false
+	}
+	return true
+}
+
+// Eventually asserts that condition returns true within timeout, checking it every millisecond.
+func Eventually(t TestingT, condition func() bool, timeout time.Duration) bool {
+	if h, ok := t.(tHelper); ok { h.Helper() }
+
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Errorf("condition not met within %s", timeout)
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
+func errorMatcher(matcher interface{}) func(err error) bool {
+	switch matcher := matcher.(type) {
+	case nil:
+		return func(err error) bool { return true }
+	case func(error) bool:
+		return matcher
+	case string:
+		return func(err error) bool { return err != nil && err.Error() == matcher }
+	case reflect.Type:
+		if !matcher.Implements(reflect.TypeOf((*error)(nil)).Elem()) { panic("matcher type must implement error") }
+		return func(err error) bool { return errors.As(err, reflect.New(matcher).Interface()) }
+	case error:
+		return func(err error) bool { return errors.Is(err, matcher) }
+	}
+	panic(fmt.Sprintf("unsupported error matcher %T", matcher))
+}
+
+func describe(p promise.Promise) string {
+	if label := p.Label(); label != "" {
+		return fmt.Sprintf("%q", label)
+	}
+	return fmt.Sprintf("%p", p)
+}
+
+func describeOutcome(p promise.Promise) string {
+	switch p.State() {
+	case promise.STATE_FULFILLED:
+		return fmt.Sprintf("fulfilled with: %v", p.Results())
+	case promise.STATE_REJECTED:
+		return fmt.Sprintf("rejected with: %v", p.Error())
+	}
+	return "pending"
+}
@@ -0,0 +1,116 @@
+package promisetest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeT struct {
+	failures []string
+}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+type codeError struct {
+	code int
+}
+
+func (e *codeError) Error() string {
+	return fmt.Sprintf("code %d", e.code)
+}
+
+func TestAssertFulfilled(t *testing.T) {
+	AssertFulfilled(t, promise.Resolve("a", 1), time.Second, "a", 1)
+	AssertFulfilled(t, promise.Resolve("a", 1), time.Second)
+
+	ft := &fakeT{}
+	assert.False(t, AssertFulfilled(ft, promise.Resolve("a"), time.Second, "b"))
+	assert.False(t, AssertFulfilled(ft, promise.Reject(errors.New("rejected")), time.Second))
+	assert.False(t, AssertFulfilled(ft, promise.NewPromise(func(resolve func(...interface{}), reject func(error)) {}), time.Millisecond))
+	assert.Len(t, ft.failures, 3)
+	assert.Contains(t, ft.failures[2], "still pending")
+}
+
+func TestAssertRejectedMatchers(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	wrapped := func() promise.Promise {
+		return promise.Reject(fmt.Errorf("wrapped: %w", sentinel))
+	}
+	AssertRejected(t, wrapped(), time.Second, nil)
+	AssertRejected(t, wrapped(), time.Second, sentinel)
+	AssertRejected(t, wrapped(), time.Second, "wrapped: sentinel")
+	AssertRejected(t, wrapped(), time.Second, func(err error) bool { return errors.Is(err, sentinel) })
+	AssertRejected(t, promise.Reject(&codeError{7}), time.Second, reflect.TypeOf(&codeError{}))
+
+	ft := &fakeT{}
+	assert.False(t, AssertRejected(ft, wrapped(), time.Second, reflect.TypeOf(&codeError{})))
+	assert.False(t, AssertRejected(ft, promise.Resolve(1), time.Second, nil))
+	assert.Len(t, ft.failures, 2)
+}
+
+func TestAssertPending(t *testing.T) {
+	release := make(chan struct{})
+	pending := promise.NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		go func() {
+			<-release
+			resolve()
+		}()
+	})
+	AssertPending(t, pending, 10 * time.Millisecond)
+	close(release)
+	AssertFulfilled(t, pending, time.Second)
+
+	ft := &fakeT{}
+	assert.False(t, AssertPending(ft, pending, 0))
+	assert.Len(t, ft.failures, 1)
+}
+
+func TestEventually(t *testing.T) {
+	started := time.Now()
+	Eventually(t, func() bool { return time.Since(started) > 5 * time.Millisecond }, time.Second)
+
+	ft := &fakeT{}
+	assert.False(t, Eventually(ft, func() bool { return false }, time.Millisecond))
+	assert.Len(t, ft.failures, 1)
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := NewRecorder()
+	promise.Reject(errors.New("rejected")).
+		Then(func() {
+			recorder.Record("skipped")
+		}).
+		Intercept(recorder).
+		Catch(func(err error) {
+			recorder.Record("caught")
+		}).
+		Finally(func() {
+			recorder.Record("finally")
+		})
+
+	recorder.AssertEvents(t, time.Second, "CATCH", "caught", "FINALLY", "finally")
+}
+
+type subscribeFunc func(onFulfilled func(values ...interface{}), onRejected func(err error))
+
+func (f subscribeFunc) Subscribe(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+	f(onFulfilled, onRejected)
+}
+
+func TestAwaitThenable(t *testing.T) {
+	assert.True(t, Await(subscribeFunc(func(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+		go onFulfilled()
+	}), time.Second))
+	assert.True(t, Await(subscribeFunc(func(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+		go onRejected(errors.New("rejected"))
+	}), time.Second))
+	assert.False(t, Await(subscribeFunc(func(onFulfilled func(values ...interface{}), onRejected func(err error)) {}),
+		time.Millisecond))
+}
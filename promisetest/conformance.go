@@ -0,0 +1,233 @@
+package promisetest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Factory creates a pending promise of the implementation under test together with the functions that settle it. The
+// implementation may be any type implementing promise.Promise, the interface has no unexported methods.
+type Factory func() (p promise.Promise, resolve func(values ...interface{}), reject func(error))
+
+// NewDeferred is the Factory of PromiseProto, creating promises with promise.NewPromise.
+func NewDeferred() (p promise.Promise, resolve func(values ...interface{}), reject func(error)) {
+	ready := make(chan struct{})
+	p = promise.NewPromise(func(resolveFunc func(...interface{}), rejectFunc func(error)) {
+		resolve, reject = resolveFunc, rejectFunc
+		close(ready)
+	})
+	<-ready
+	return p, resolve, reject
+}
+
+// ConformanceTimeout is how long Conformance waits for a promise to settle.
+var ConformanceTimeout = time.Second
+
+type conformanceError struct {
+	code int
+}
+
+func (e *conformanceError) Error() string {
+	return fmt.Sprintf("conformance error %d", e.code)
+}
+
+// Conformance runs the behaviour of PromiseProto against the promises created by factory: state transitions, chaining,
+// error propagation through Then, Catch and Finally, and the combinators. Each check is a subtest.
+func Conformance(t *testing.T, factory Factory) {
+	timeout := ConformanceTimeout
+	failure := errors.New("failure")
+
+	fulfilled := func(values ...interface{}) promise.Promise {
+		p, resolve, _ := factory()
+		resolve(values...)
+		return p
+	}
+	rejected := func(err error) promise.Promise {
+		p, _, reject := factory()
+		reject(err)
+		return p
+	}
+
+	t.Run("StaysPendingUntilSettled", func(t *testing.T) {
+		p, resolve, _ := factory()
+		AssertPending(t, p, 10 * time.Millisecond)
+		resolve("a", 1)
+		AssertFulfilled(t, p, timeout, "a", 1)
+	})
+
+	t.Run("SettlesOnlyOnce", func(t *testing.T) {
+		p, resolve, reject := factory()
+		resolve(1)
+		resolve(2)
+		reject(failure)
+		AssertFulfilled(t, p, timeout, 1)
+
+		p, resolve, reject = factory()
+		reject(failure)
+		resolve(1)
+		AssertRejected(t, p, timeout, failure)
+	})
+
+	t.Run("ResolveAdoptsPromises", func(t *testing.T) {
+		inner, resolveInner, _ := factory()
+		p, resolve, _ := factory()
+		resolve(inner)
+		AssertPending(t, p, 10 * time.Millisecond)
+		resolveInner(fulfilled(1))
+		AssertFulfilled(t, p, timeout, 1)
+
+		p, resolve, _ = factory()
+		resolve(rejected(failure))
+		AssertRejected(t, p, timeout, failure)
+	})
+
+	t.Run("ThenReceivesResults", func(t *testing.T) {
+		p := fulfilled("a", 1).Then(func(a string, b int) (string, error) {
+			return fmt.Sprint(a, b), nil
+		})
+		AssertFulfilled(t, p, timeout, "a1")
+	})
+
+	t.Run("ThenAdoptsReturnedPromise", func(t *testing.T) {
+		adopted, resolve, _ := factory()
+		p := fulfilled("adopt").Then(func(value string) promise.Promise {
+			return adopted
+		})
+		AssertPending(t, p, 10 * time.Millisecond)
+		resolve("adopted")
+		AssertFulfilled(t, p, timeout, "adopted")
+	})
+
+	t.Run("SiblingHandlersRunInRegistrationOrder", func(t *testing.T) {
+		p, resolve, _ := factory()
+		recorder := NewRecorder()
+		for _, event := range []string{"first", "second", "third"} {
+			event := event
+			p.Then(func() {
+				recorder.Record(event)
+			})
+		}
+		resolve()
+		recorder.AssertEvents(t, timeout, "first", "second", "third")
+	})
+
+	t.Run("RejectionSkipsThen", func(t *testing.T) {
+		recorder := NewRecorder()
+		p := rejected(failure).
+			Then(func() {
+				recorder.Record("then")
+			}).
+			Catch(func(err error) (string, error) {
+				return err.Error(), nil
+			})
+		AssertFulfilled(t, p, timeout, "failure")
+		assert.Empty(t, recorder.Events())
+	})
+
+	t.Run("ThenRejectorHandlesRejection", func(t *testing.T) {
+		p := rejected(failure).Then(func() {}, func(err error) (string, error) {
+			return "recovered", nil
+		})
+		AssertFulfilled(t, p, timeout, "recovered")
+	})
+
+	t.Run("ReturnedErrorRejects", func(t *testing.T) {
+		p := fulfilled(1).Then(func(value int) error {
+			return failure
+		})
+		AssertRejected(t, p, timeout, failure)
+	})
+
+	t.Run("PanicRejectsWithProgrammerError", func(t *testing.T) {
+		p := fulfilled().Then(func() {
+			panic("boom")
+		})
+		AssertRejected(t, p, timeout, promise.IsProgrammerError)
+
+		caught := make(chan struct{})
+		p.Catch(func(err error) {
+			close(caught)
+		})
+		select {
+		case <-caught:
+			t.Error("a generic Catch handled a programmer error")
+		case <-time.After(10 * time.Millisecond):
+		}
+	})
+
+	t.Run("TypedCatchFiltersErrors", func(t *testing.T) {
+		p := rejected(failure).
+			Catch(func(err *conformanceError) (string, error) {
+				return "typed", nil
+			})
+		AssertRejected(t, p, timeout, failure)
+
+		p = rejected(fmt.Errorf("wrapped: %w", &conformanceError{7})).
+			Catch(func(err *conformanceError) (int, error) {
+				return err.code, nil
+			})
+		AssertFulfilled(t, p, timeout, 7)
+	})
+
+	t.Run("FinallyKeepsOutcome", func(t *testing.T) {
+		recorder := NewRecorder()
+		AssertFulfilled(t, fulfilled("kept").Finally(func() {
+			recorder.Record("fulfilled")
+		}), timeout, "kept")
+		AssertRejected(t, rejected(failure).Finally(func() {
+			recorder.Record("rejected")
+		}), timeout, failure)
+		assert.Equal(t, []string{"fulfilled", "rejected"}, recorder.Events())
+	})
+
+	t.Run("FinallyErrorOverridesOutcome", func(t *testing.T) {
+		override := errors.New("override")
+		p := fulfilled("kept").Finally(func() error {
+			return override
+		})
+		AssertRejected(t, p, timeout, override)
+	})
+
+	t.Run("All", func(t *testing.T) {
+		first, resolveFirst, _ := factory()
+		second, resolveSecond, _ := factory()
+		p := promise.All(first, second)
+		resolveSecond(2)
+		AssertPending(t, p, 10 * time.Millisecond)
+		resolveFirst(1)
+		AssertFulfilled(t, p, timeout, []interface{}{1, 2})
+
+		AssertFulfilled(t, promise.All(fulfilled("a", 1), fulfilled()), timeout, []interface{}{promise.Tuple{"a", 1}, nil})
+		AssertFulfilled(t, promise.All(), timeout, []interface{}{})
+		pending, _, _ := factory()
+		AssertRejected(t, promise.All(pending, rejected(failure)), timeout, failure)
+	})
+
+	t.Run("Any", func(t *testing.T) {
+		AssertFulfilled(t, promise.Any(rejected(failure), fulfilled("any")), timeout, "any")
+		AssertRejected(t, promise.Any(rejected(failure), rejected(failure)), timeout,
+			reflect.TypeOf(&promise.AggregateError{}))
+	})
+
+	t.Run("Race", func(t *testing.T) {
+		pending, _, _ := factory()
+		AssertFulfilled(t, promise.Race(pending, fulfilled("a", 1)), timeout, "a", 1)
+		AssertRejected(t, promise.Race(pending, rejected(failure)), timeout, failure)
+		AssertFulfilled(t, promise.RaceIndexed(pending, fulfilled("race")), timeout, 1, "race")
+		AssertRejected(t, promise.RaceIndexed(pending, rejected(failure)), timeout, func(err error) bool {
+			var indexed *promise.IndexedError
+			return errors.As(err, &indexed) && indexed.Index == 1 && errors.Is(err, failure)
+		})
+	})
+
+	t.Run("Some", func(t *testing.T) {
+		AssertFulfilled(t, promise.Some(2, fulfilled(1), rejected(failure), fulfilled(1)), timeout, []interface{}{1, 1})
+		AssertRejected(t, promise.Some(2, fulfilled(1), rejected(failure), rejected(failure)), timeout,
+			reflect.TypeOf(&promise.AggregateError{}))
+	})
+}
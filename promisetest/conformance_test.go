@@ -0,0 +1,39 @@
+package promisetest
+
+import (
+	"github.com/vellotis/go-bird"
+	"testing"
+)
+
+// batchPromise is a Promise implemented outside of go-bird. It delegates to a PromiseProto, but the promises it hands
+// out from Then, Catch and Finally are batchPromises again, so the whole suite runs against the foreign type.
+type batchPromise struct {
+	promise.Promise
+}
+
+var _ promise.Promise = batchPromise{}
+
+func newBatchDeferred() (p promise.Promise, resolve func(values ...interface{}), reject func(error)) {
+	p, resolve, reject = NewDeferred()
+	return batchPromise{p}, resolve, reject
+}
+
+func (p batchPromise) Then(resolver interface{}, rejector ...interface{}) promise.Promise {
+	return batchPromise{p.Promise.Then(resolver, rejector...)}
+}
+
+func (p batchPromise) Catch(handler interface{}) promise.Promise {
+	return batchPromise{p.Promise.Catch(handler)}
+}
+
+func (p batchPromise) Finally(handler interface{}) promise.Promise {
+	return batchPromise{p.Promise.Finally(handler)}
+}
+
+func TestConformanceOfPromiseProto(t *testing.T) {
+	Conformance(t, NewDeferred)
+}
+
+func TestConformanceOfForeignPromise(t *testing.T) {
+	Conformance(t, newBatchDeferred)
+}
@@ -0,0 +1,45 @@
+package promisetest
+
+import (
+	"bytes"
+	"github.com/vellotis/go-bird"
+	"testing"
+	"time"
+)
+
+// LeakTimeout is how long VerifyNoLeaks waits for promises to settle and tasks to return before reporting them.
+var LeakTimeout = time.Second
+
+// VerifyNoLeaks fails the test if, once it has finished, promises created during it are still pending or goroutines
+// started by go-bird during it are still running. It is called at the start of the test:
+//
+//	func TestExport(t *testing.T) {
+//		promisetest.VerifyNoLeaks(t)
+//		...
+//	}
+//
+// It turns on the tracking of pending promises for the duration of the test, so tests using it must not run in
+// parallel with others. Only goroutines of the default scheduler are watched.
+func VerifyNoLeaks(t testing.TB) {
+	t.Helper()
+
+	wasTracking := promise.TrackPending(true)
+	baseline := promise.RunningTasks()
+
+	t.Cleanup(func() {
+		defer promise.TrackPending(wasTracking)
+
+		deadline := time.Now().Add(LeakTimeout)
+		for promise.PendingCount() > 0 || promise.RunningTasks() > baseline {
+			if time.Now().After(deadline) {
+				var report bytes.Buffer
+				promise.DumpPending(&report)
+				report.WriteString("\n")
+				promise.DumpTasks(&report)
+				t.Errorf("found leaked promises or goroutines after %s:\n%s", LeakTimeout, report.String())
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
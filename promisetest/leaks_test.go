@@ -0,0 +1,58 @@
+package promisetest
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"testing"
+	"time"
+)
+
+type cleanupT struct {
+	*testing.T
+	cleanups []func()
+	failures []string
+}
+
+func (t *cleanupT) Cleanup(cleanup func()) {
+	t.cleanups = append(t.cleanups, cleanup)
+}
+
+func (t *cleanupT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func (t *cleanupT) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestVerifyNoLeaks(t *testing.T) {
+	VerifyNoLeaks(t)
+
+	AssertFulfilled(t, promise.Resolve(1).Then(func(value int) (int, error) {
+		return value + 1, nil
+	}), time.Second, 2)
+}
+
+func TestVerifyNoLeaksReportsLeaks(t *testing.T) {
+	// Prepare
+	defer func(timeout time.Duration) { LeakTimeout = timeout }(LeakTimeout)
+	LeakTimeout = 10 * time.Millisecond
+	release := make(chan struct{})
+	ct := &cleanupT{T: t}
+	// Test
+	VerifyNoLeaks(ct)
+	promise.NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		<-release
+		resolve()
+	}).Named("blocked")
+	ct.finish()
+	close(release)
+	// Verify
+	if assert.Len(t, ct.failures, 1) {
+		assert.Contains(t, ct.failures[0], `"blocked" EXECUTOR`)
+		assert.Contains(t, ct.failures[0], "TestVerifyNoLeaksReportsLeaks")
+	}
+}
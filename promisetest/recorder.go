@@ -0,0 +1,59 @@
+package promisetest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"sync"
+	"time"
+)
+
+// Recorder captures events in the order they happen. Handlers record events of their own with Record, and as an
+// interceptor, registered with Promise.Intercept or promise.AddInterceptor, it records every handler invocation as
+// its kind, followed by the label of the promise if it has one, like "THEN" or "CATCH fetch".
+type Recorder struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (this *Recorder) Record(event string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.events = append(this.events, event)
+}
+
+func (this *Recorder) Before(invocation *promise.Invocation) {
+	event := string(invocation.Kind)
+	if label := invocation.Promise.Label(); label != "" {
+		event += " " + label
+	}
+	this.Record(event)
+}
+
+func (this *Recorder) After(invocation *promise.Invocation) {}
+
+// Events returns the events recorded so far.
+func (this *Recorder) Events() []string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return append([]string(nil), this.events...)
+}
+
+// AssertEvents waits up to timeout for as many events as expected to be recorded and asserts that they are the
+// expected ones, in the same order.
+func (this *Recorder) AssertEvents(t TestingT, timeout time.Duration, expected ...string) bool {
+	if h, ok := t.(tHelper); ok { h.Helper() }
+
+	deadline := time.Now().Add(timeout)
+	events := this.Events()
+	for len(events) < len(expected) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		events = this.Events()
+	}
+	return assert.Equal(t, expected, events, "recorded events")
+}
@@ -0,0 +1,117 @@
+package promise_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentThenWhileSettling(t *testing.T) {
+	// Prepare
+	const handlers = 200
+	release := make(chan struct{})
+	settling := gated(release, func(resolve func(...interface{}), reject func(error)) {
+		resolve(42)
+	})
+	derived := make([]promise.Promise, handlers)
+	// Test
+	var registered sync.WaitGroup
+	for i := range derived {
+		i := i
+		registered.Add(1)
+		go func() {
+			defer registered.Done()
+			derived[i] = settling.Then(func(value int) (int, error) {
+				return value, nil
+			})
+			assert.Contains(t, []promise.PromiseState{promise.STATE_PENDING, promise.STATE_FULFILLED}, settling.State())
+		}()
+		if i == handlers / 2 {
+			close(release)
+		}
+	}
+	registered.Wait()
+	// Verify
+	for _, p := range derived {
+		promisetest.AssertFulfilled(t, p, settleTimeout, 42)
+	}
+	assert.Equal(t, []interface{}{42}, settling.Results())
+}
+
+func TestTapAndFinallyKeepResults(t *testing.T) {
+	// Test
+	p := promise.Resolve("resolved").
+		Tap(func(value string) error {
+			return nil
+		}).
+		Finally(func() (string, error) {
+			return "finally", nil
+		})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "resolved")
+}
+
+func TestFinallyRejectionOverridesResults(t *testing.T) {
+	// Test
+	p := promise.Resolve("resolved").
+		Finally(func() error {
+			return errors.New("finally")
+		})
+	// Verify
+	promisetest.AssertRejected(t, p, settleTimeout, "finally")
+}
+
+func TestHandlersRegisteredFromHandlersOnSamePromise(t *testing.T) {
+	// Prepare
+	recorder := promisetest.NewRecorder()
+	resolved := promise.Resolve("resolved")
+	// Test
+	resolved.Then(func(value string) {
+		resolved.Then(func(value string) {
+			recorder.Record("then")
+		})
+		resolved.Tap(func(value string) {
+			recorder.Record("tap")
+		})
+		resolved.Finally(func() {
+			resolved.Then(func(value string) {
+				recorder.Record("nested")
+			})
+			recorder.Record("finally")
+		})
+	})
+	// Verify
+	recorder.AssertEvents(t, settleTimeout, "then", "tap", "finally", "nested")
+}
+
+func TestProgressHandlerRegisteredFromProgressHandler(t *testing.T) {
+	// Prepare
+	start := make(chan struct{})
+	registered := make(chan struct{})
+	recorder := promisetest.NewRecorder()
+	// Test
+	var p promise.Promise
+	p = promise.NewPromise(func(resolve func(...interface{}), reject func(error), progress func(interface{})) {
+		<-start
+		progress(1)
+		<-registered
+		progress(2)
+		resolve()
+	})
+	p.OnProgress(func(value interface{}) {
+		if value == 1 {
+			p.OnProgress(func(value interface{}) {
+				recorder.Record(fmt.Sprint("inner ", value))
+			})
+			close(registered)
+		}
+		recorder.Record(fmt.Sprint(value))
+	})
+	close(start)
+	// Verify
+	recorder.AssertEvents(t, settleTimeout, "1", "2", "inner 2")
+}
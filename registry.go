@@ -0,0 +1,144 @@
+package promise
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+type pendingEntry struct {
+	id      uint64
+	promise *PromiseProto
+	parent  *PromiseProto
+	created time.Time
+	stack   []uintptr
+}
+
+var pendingRegistry struct {
+	sync.Mutex
+	enabled bool
+	nextId  uint64
+	entries map[*PromiseProto]*pendingEntry
+}
+
+// TrackPending turns the registry of pending promises on or off and reports whether it was on before. Only promises
+// created while it is on are tracked, turning it on or off forgets all of them.
+func TrackPending(enabled bool) (wasEnabled bool) {
+	pendingRegistry.Lock()
+	defer pendingRegistry.Unlock()
+
+	wasEnabled = pendingRegistry.enabled
+	pendingRegistry.enabled = enabled
+	pendingRegistry.entries = nil
+	return wasEnabled
+}
+
+// PendingCount reports how many of the tracked promises have not settled yet. See TrackPending.
+func PendingCount() int {
+	pendingRegistry.Lock()
+	defer pendingRegistry.Unlock()
+
+	return len(pendingRegistry.entries)
+}
+
+// The kind is set before the promise is registered, since DumpPending reads it without the lock of the promise.
+func newPromiseProto(kind HandlerKind) *PromiseProto {
+	promise := &PromiseProto{kind: kind}
+
+	pendingRegistry.Lock()
+	defer pendingRegistry.Unlock()
+
+	if pendingRegistry.enabled {
+		if pendingRegistry.entries == nil {
+			pendingRegistry.entries = make(map[*PromiseProto]*pendingEntry)
+		}
+		pendingRegistry.nextId++
+		stack := make([]uintptr, 32)
+		pendingRegistry.entries[promise] = &pendingEntry{
+			id:      pendingRegistry.nextId,
+			promise: promise,
+			created: currentClock().Now(),
+			stack:   stack[:runtime.Callers(2, stack)],
+		}
+	}
+	return promise
+}
+
+func trackParent(promise, parent *PromiseProto) {
+	pendingRegistry.Lock()
+	defer pendingRegistry.Unlock()
+
+	if entry, found := pendingRegistry.entries[promise]; found {
+		entry.parent = parent
+	}
+}
+
+func untrack(promise *PromiseProto) {
+	pendingRegistry.Lock()
+	defer pendingRegistry.Unlock()
+
+	delete(pendingRegistry.entries, promise)
+}
+
+// DumpPending writes the tracked promises that have not settled yet, oldest first, together with their labels, ages,
+// parents and the stacks they were created from. See TrackPending.
+func DumpPending(w io.Writer) error {
+	pendingRegistry.Lock()
+	entries := make([]*pendingEntry, 0, len(pendingRegistry.entries))
+	ids := make(map[*PromiseProto]uint64, len(pendingRegistry.entries))
+	for _, entry := range pendingRegistry.entries {
+		entries = append(entries, entry)
+		ids[entry.promise] = entry.id
+	}
+	enabled := pendingRegistry.enabled
+	pendingRegistry.Unlock()
+
+	if !enabled {
+		_, err := fmt.Fprintln(w, "pending promise tracking is disabled")
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].id < entries[j].id })
+
+	if _, err := fmt.Fprintf(w, "%d pending promises\n", len(entries)); err != nil {
+		return err
+	}
+	now := currentClock().Now()
+	for _, entry := range entries {
+		if _, err := fmt.Fprintf(w, "\n#%d %q %s, pending for %s\n", entry.id, entry.promise.Label(), entry.promise.kind,
+			now.Sub(entry.created)); err != nil {
+			return err
+		}
+		if entry.parent != nil {
+			parent := "settled"
+			if id, found := ids[entry.parent]; found {
+				parent = fmt.Sprintf("#%d", id)
+			}
+			if _, err := fmt.Fprintf(w, "  parent: %s %q\n", parent, entry.parent.Label()); err != nil {
+				return err
+			}
+		}
+		frames := runtime.CallersFrames(entry.stack)
+		for more := len(entry.stack) > 0; more; {
+			var frame runtime.Frame
+			frame, more = frames.Next()
+			if _, err := fmt.Fprintf(w, "  %s\n      %s:%d\n", frame.Function, frame.File, frame.Line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PendingHandler serves the DumpPending output as plain text, in the manner of net/http/pprof.
+func PendingHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		DumpPending(w)
+	})
+}
@@ -0,0 +1,56 @@
+package promise
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestDumpPending(t *testing.T) {
+	// Prepare
+	TrackPending(true)
+	defer TrackPending(false)
+	release := make(chan struct{})
+	defer close(release)
+	// Test
+	NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		go func() {
+			<-release
+			resolve()
+		}()
+	}).Named("export").Then(func() {}).Named("upload")
+	resolved := make(chan struct{})
+	Resolve().Named("resolved").(*PromiseProto).addStateCompleteListener(func(state callbackState) {
+		close(resolved)
+	})
+	<-resolved
+	// Verify
+	var dump bytes.Buffer
+	assert.NoError(t, DumpPending(&dump))
+	assert.Regexp(t, regexp.MustCompile(`(?s)^2 pending promises\n\n#\d+ "export" EXECUTOR, pending for .*TestDumpPending.*#\d+ "upload" THEN, pending for .*\n  parent: #\d+ "export"\n`), dump.String())
+	assert.NotContains(t, dump.String(), "resolved")
+}
+
+func TestPendingHandler(t *testing.T) {
+	// Prepare
+	recorder := httptest.NewRecorder()
+	// Test
+	PendingHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/promises", nil))
+	// Verify
+	assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "pending promise tracking is disabled\n", recorder.Body.String())
+}
+
+func TestPendingKindIsSetWhenRegistered(t *testing.T) {
+	// Prepare
+	TrackPending(true)
+	defer TrackPending(false)
+	// Test
+	newPromiseProto(KIND_CATCH).Named("registered")
+	// Verify
+	var dump bytes.Buffer
+	assert.NoError(t, DumpPending(&dump))
+	assert.Contains(t, dump.String(), `"registered" CATCH, pending for`)
+}
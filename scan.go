@@ -0,0 +1,131 @@
+package promise
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Scan waits until the promise settles and assigns its results to dest, one pointer per result, in the manner of
+// database/sql.Rows.Scan. Results are converted like handler parameters are, a nil result sets the zero value. It
+// returns the rejection reason if the promise rejects, and ctx.Err() if ctx is done first. A result that does not fit
+// its destination is reported as a *TypeMismatchError.
+func (this *PromiseProto) Scan(ctx context.Context, dest ...interface{}) error {
+	results, err := this.await(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(dest) != len(results) {
+		return fmt.Errorf("scan: expected %d destination arguments, not %d", len(results), len(dest))
+	}
+	for i, target := range dest {
+		targetValue := reflect.ValueOf(target)
+		if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() {
+			return fmt.Errorf("scan: destination %d is not a non-nil pointer: %T", i, target)
+		}
+		if err := assignResult(targetValue.Elem(), results[i], i, fmt.Sprintf("destination %d", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanStruct waits like Scan and assigns the results to the fields of the struct dest points to. A single result that
+// is a map with string keys is assigned by key, matching the `promise:"name"` tag of a field or else its name, ignoring
+// case. Fields without a matching key keep their values and keys without a matching field are ignored. Otherwise the
+// results are assigned to the exported fields in the order they are declared, so there must be as many results as
+// fields. Fields tagged `promise:"-"` are left out.
+func (this *PromiseProto) ScanStruct(ctx context.Context, dest interface{}) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() || destValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("scan: destination is not a non-nil pointer to a struct: %T", dest)
+	}
+
+	results, err := this.await(ctx)
+	if err != nil {
+		return err
+	}
+
+	fields := scanFields(destValue.Elem())
+	if len(results) == 1 && results[0].Kind() == reflect.Map && results[0].Type().Key().Kind() == reflect.String {
+		for i, field := range fields {
+			for _, key := range results[0].MapKeys() {
+				if strings.EqualFold(key.String(), field.name) {
+					value := dynamicValues([]reflect.Value{results[0].MapIndex(key)})[0]
+					if err := assignResult(field.value, value, i, "field " + field.field); err != nil {
+						return err
+					}
+					break
+				}
+			}
+		}
+		return nil
+	}
+
+	if len(fields) != len(results) {
+		return fmt.Errorf("scan: expected %d results for the fields of %T, got %d", len(fields), dest, len(results))
+	}
+	for i, field := range fields {
+		if err := assignResult(field.value, results[i], i, "field " + field.field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *PromiseProto) await(ctx context.Context) ([]reflect.Value, error) {
+	settled := make(chan struct{})
+	this.addStateCompleteListener(func(state callbackState) {
+		close(settled)
+	})
+
+	select {
+	case <-settled:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if this.State() == STATE_REJECTED {
+		return nil, this.Error()
+	}
+	return this.results(), nil
+}
+
+type scanField struct {
+	name  string
+	field string
+	value reflect.Value
+}
+
+func scanFields(structValue reflect.Value) (fields []scanField) {
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("promise")
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		name := field.Name
+		if tag != "" {
+			name = tag
+		}
+		fields = append(fields, scanField{name, field.Name, structValue.Field(i)})
+	}
+	return fields
+}
+
+// A mismatch is reported for targetName, or for a handler parameter if it is empty.
+func assignResult(target reflect.Value, result reflect.Value, index int, targetName string) error {
+	switch {
+	case !result.IsValid():
+		target.Set(reflect.Zero(target.Type()))
+	case result.Type().AssignableTo(target.Type()):
+		target.Set(result)
+	case isConvertible(result, target.Type()):
+		target.Set(result.Convert(target.Type()))
+	default:
+		return &TypeMismatchError{index, target.Type(), result.Type(), targetName}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package promise_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScan(t *testing.T) {
+	// Prepare
+	var (
+		name  string
+		count int64
+		extra interface{}
+		user  *nilValue
+	)
+	// Test
+	err := promise.Resolve("name", 3, "extra", nil).Scan(context.Background(), &name, &count, &extra, &user)
+	// Verify
+	assert.NoError(t, err)
+	assert.Equal(t, "name", name)
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, "extra", extra)
+	assert.Nil(t, user)
+}
+
+func TestScanErrors(t *testing.T) {
+	// Prepare
+	failure := errors.New("failure")
+	var count int
+	ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Millisecond)
+	defer cancel()
+		// Test
+	rejectedErr := promise.Reject(failure).Scan(context.Background(), &count)
+	mismatchErr := promise.Resolve("three").Scan(context.Background(), &count)
+	countErr := promise.Resolve(1, 2).Scan(context.Background(), &count)
+	pointerErr := promise.Resolve(1).Scan(context.Background(), count)
+	timeoutErr := pending().Scan(ctx, &count)
+	// Verify
+	assert.Equal(t, failure, rejectedErr)
+	assert.Equal(t, &promise.TypeMismatchError{
+		Index: 0, Expected: reflect.TypeOf(0), Actual: reflect.TypeOf(""), Target: "destination 0",
+	}, mismatchErr)
+	assert.EqualError(t, mismatchErr, "scan: destination 0 expects int, got string")
+	assert.EqualError(t, countErr, "scan: expected 2 destination arguments, not 1")
+	assert.EqualError(t, pointerErr, "scan: destination 0 is not a non-nil pointer: int")
+	assert.Equal(t, context.DeadlineExceeded, timeoutErr)
+}
+
+type scannedUser struct {
+	Id      int64
+	Name    string `promise:"login"`
+	Skipped string `promise:"-"`
+	private string
+}
+
+func TestScanStruct(t *testing.T) {
+	// Prepare
+	var positional, mapped scannedUser
+	// Test
+	positionalErr := promise.Resolve(1, "alice").ScanStruct(context.Background(), &positional)
+	mappedErr := promise.Resolve(map[string]interface{}{"ID": 2, "login": "bob", "Skipped": "no"}).
+		ScanStruct(context.Background(), &mapped)
+	countErr := promise.Resolve(1).ScanStruct(context.Background(), &positional)
+	// Verify
+	assert.NoError(t, positionalErr)
+	assert.Equal(t, scannedUser{Id: 1, Name: "alice"}, positional)
+	assert.NoError(t, mappedErr)
+	assert.Equal(t, scannedUser{Id: 2, Name: "bob"}, mapped)
+	assert.EqualError(t, countErr, "scan: expected 2 results for the fields of *promise_test.scannedUser, got 1")
+}
+
+func TestScanStructErrors(t *testing.T) {
+	// Prepare
+	var positional, mapped scannedUser
+	// Test
+	positionalErr := promise.Resolve(1, 2).ScanStruct(context.Background(), &positional)
+	mappedErr := promise.Resolve(map[string]interface{}{"login": true}).ScanStruct(context.Background(), &mapped)
+	// Verify
+	assert.EqualError(t, positionalErr, "scan: field Name expects string, got int")
+	assert.EqualError(t, mappedErr, "scan: field Name expects string, got bool")
+}
+
+func TestScanStructKeepsFieldsWithoutKey(t *testing.T) {
+	// Prepare
+	user := scannedUser{Id: 1, Name: "alice"}
+	// Test
+	err := promise.Resolve(map[string]interface{}{"login": "bob", "unknown": 3}).
+		ScanStruct(context.Background(), &user)
+	// Verify
+	assert.NoError(t, err)
+	assert.Equal(t, scannedUser{Id: 1, Name: "bob"}, user)
+}
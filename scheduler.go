@@ -0,0 +1,112 @@
+package promise
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// Scheduler runs the work of promises: executors, handlers and the listeners notified when a promise settles or
+// reports progress.
+type Scheduler interface {
+	Schedule(task func())
+}
+
+// SchedulerFunc adapts a plain function to the Scheduler interface.
+type SchedulerFunc func(task func())
+
+func (scheduler SchedulerFunc) Schedule(task func()) {
+	scheduler(task)
+}
+
+// The default scheduler runs every task on a goroutine of its own.
+var goScheduler = SchedulerFunc(func(task func()) {
+	atomic.AddInt64(&runningTasks, 1)
+	go runTask(task)
+})
+
+var runningTasks int64
+
+// Every goroutine of the default scheduler starts here, which is how DumpTasks tells them apart from the others.
+func runTask(task func()) {
+	defer atomic.AddInt64(&runningTasks, -1)
+	task()
+}
+
+// The synchronous scheduler runs every task inline, on the goroutine that scheduled it.
+var syncScheduler = SchedulerFunc(func(task func()) {
+	task()
+})
+
+type schedulerHolder struct{ Scheduler }
+
+var globalScheduler atomic.Value
+
+// SetScheduler replaces the scheduler used by all promises, nil restores the default one. The returned function puts
+// back the scheduler that was in use before.
+func SetScheduler(scheduler Scheduler) (restore func()) {
+	if scheduler == nil {
+		scheduler = goScheduler
+	}
+	previous := currentScheduler()
+	globalScheduler.Store(schedulerHolder{scheduler})
+	return func() {
+		globalScheduler.Store(schedulerHolder{previous})
+	}
+}
+
+// WithSyncExecution makes promises run executors, handlers and listeners inline on the calling goroutine until the
+// returned function is called. A chain of promises that settle without waiting on anything else, like
+// Resolve(1).Then(handler), is then settled as soon as the expression returns, and a panic in a handler shows the
+// whole chain in its stack. An executor or handler that blocks, blocks its caller.
+//
+// Meant for unit tests. The mode is global, so tests using it must not run in parallel with others.
+func WithSyncExecution() (restore func()) {
+	return SetScheduler(syncScheduler)
+}
+
+func currentScheduler() Scheduler {
+	if holder, ok := globalScheduler.Load().(schedulerHolder); ok {
+		return holder.Scheduler
+	}
+	return goScheduler
+}
+
+// RunningTasks reports how many tasks of the default scheduler have not returned yet. An executor that never returns
+// keeps its task running forever. Tasks of schedulers set with SetScheduler are not counted.
+func RunningTasks() int {
+	return int(atomic.LoadInt64(&runningTasks))
+}
+
+// DumpTasks writes the stacks of the goroutines running tasks of the default scheduler.
+func DumpTasks(w io.Writer) error {
+	buffer := make([]byte, 1 << 16)
+	for {
+		if n := runtime.Stack(buffer, true); n < len(buffer) {
+			buffer = buffer[:n]
+			break
+		}
+		buffer = make([]byte, 2 * len(buffer))
+	}
+
+	taskFrame := runtime.FuncForPC(reflect.ValueOf(runTask).Pointer()).Name() + "("
+	var tasks []string
+	for _, goroutine := range bytes.Split(buffer, []byte("\n\n")) {
+		if bytes.Contains(goroutine, []byte(taskFrame)) {
+			tasks = append(tasks, string(goroutine))
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "%d running tasks\n", len(tasks)); err != nil {
+		return err
+	}
+	if len(tasks) > 0 {
+		_, err := fmt.Fprintf(w, "\n%s\n", strings.Join(tasks, "\n\n"))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package promise_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSyncExecutionSettlesInline(t *testing.T) {
+	// Prepare
+	defer promise.WithSyncExecution()()
+	var order []string
+	// Test
+	fulfilled := promise.Resolve(1).
+		Then(func(value int) (int, error) {
+			order = append(order, "then")
+			return value + 1, nil
+		}).
+		Finally(func() {
+			order = append(order, "finally")
+		})
+	rejected := promise.Reject(errors.New("rejected")).
+		Then(func() {
+			order = append(order, "skipped")
+		}).
+		Catch(func(err error) (string, error) {
+			order = append(order, "catch")
+			return err.Error(), nil
+		})
+	// Verify
+	assert.Equal(t, promise.STATE_FULFILLED, fulfilled.State())
+	assert.Equal(t, []interface{}{2}, fulfilled.Results())
+	assert.Equal(t, promise.STATE_FULFILLED, rejected.State())
+	assert.Equal(t, []interface{}{"rejected"}, rejected.Results())
+	assert.Equal(t, []string{"then", "finally", "catch"}, order)
+}
+
+func TestSyncExecutionReentrantRegistration(t *testing.T) {
+	// Prepare
+	defer promise.WithSyncExecution()()
+	var order []string
+	resolved := promise.Resolve("resolved")
+	// Test
+	resolved.Then(func(value string) {
+		resolved.Then(func(value string) {
+			order = append(order, "inner")
+		})
+		order = append(order, "outer")
+	})
+	// Verify
+	assert.Equal(t, []string{"outer", "inner"}, order)
+}
+
+func TestSetScheduler(t *testing.T) {
+	// Prepare
+	var scheduled int32
+	defer promise.SetScheduler(promise.SchedulerFunc(func(task func()) {
+		atomic.AddInt32(&scheduled, 1)
+		go task()
+	}))()
+	// Test
+	p := promise.Resolve(1).Then(func(value int) (int, error) {
+		return value, nil
+	})
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, 1)
+	assert.True(t, atomic.LoadInt32(&scheduled) > 0)
+}
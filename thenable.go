@@ -0,0 +1,83 @@
+package promise
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Thenable is the part of a promise that other future types can implement to take part in promise chains. A promise
+// resolved with a single Thenable, by resolve or by the return value of a handler, settles like the Thenable does, which
+// goes on until a value that is not a Thenable is reached. The combinators take Thenables and adopt them, Map adopts
+// the Thenables its mapper returns and Adopt turns one into a Promise.
+//
+// Subscribe must call exactly one of the callbacks once the Thenable settles, from any goroutine. Calls after the
+// first one are ignored.
+type Thenable interface {
+	Subscribe(onFulfilled func(values ...interface{}), onRejected func(err error))
+}
+
+// Adopt returns a promise that settles like thenable. A Promise is returned as it is.
+func Adopt(thenable Thenable) Promise {
+	if thenable == nil { panic("thenable cannot be <nil>") }
+
+	if promise, isPromise := thenable.(Promise); isPromise {
+		return promise
+	}
+	return NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		thenable.Subscribe(resolve, reject)
+	})
+}
+
+// Subscribe makes every Promise a Thenable. Either callback may be nil.
+func (this *PromiseProto) Subscribe(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+	this.addStateCompleteListener(func(state callbackState) {
+		switch state {
+		case STATE_FULFILLED:
+			if onFulfilled != nil {
+				onFulfilled(this.Results()...)
+			}
+		case STATE_REJECTED:
+			if onRejected != nil {
+				onRejected(this.Error())
+			}
+		}
+	})
+}
+
+// Completes this promise the way a Thenable implemented outside the package settles. Unless it is a Promise, such a
+// thenable knows nothing of progress or of the promises it waits on, so neither is forwarded nor checked for cycles.
+func (this *PromiseProto) adoptForeign(thenable Thenable, complete func(state callbackState, results []reflect.Value, err error) bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			complete(STATE_REJECTED, nil, newPanicError(r))
+		}
+	}()
+
+	thenable.Subscribe(func(values ...interface{}) {
+		results := make([]reflect.Value, len(values))
+		for i, value := range values {
+			results[i] = reflect.ValueOf(value)
+		}
+		if adopted, isThenable := asThenable(results); isThenable {
+			this.adopt(adopted, complete)
+			return
+		}
+		complete(STATE_FULFILLED, results, nil)
+	}, func(err error) {
+		if err == nil {
+			err = Programmer(errors.New("promise rejected with a <nil> error"))
+		}
+		complete(STATE_REJECTED, nil, err)
+	})
+}
+
+// A+ thenables: a handler that returns a single value implementing Thenable, whatever its declared type, is adopted.
+// A nil pointer is a plain value even if its type implements Thenable.
+func asThenable(results []reflect.Value) (Thenable, bool) {
+	if len(results) != 1 || !results[0].IsValid() || !results[0].CanInterface() ||
+		results[0].Kind() == reflect.Ptr && results[0].IsNil() {
+		return nil, false
+	}
+	thenable, isThenable := results[0].Interface().(Thenable)
+	return thenable, isThenable && thenable != nil
+}
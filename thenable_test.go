@@ -0,0 +1,120 @@
+package promise_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vellotis/go-bird"
+	"github.com/vellotis/go-bird/promisetest"
+	"reflect"
+	"testing"
+)
+
+type foreignFuture struct {
+	done   chan struct{}
+	values []interface{}
+	err    error
+}
+
+func newForeignFuture() *foreignFuture {
+	return &foreignFuture{done: make(chan struct{})}
+}
+
+func (f *foreignFuture) complete(err error, values ...interface{}) *foreignFuture {
+	f.values, f.err = values, err
+	close(f.done)
+	return f
+}
+
+func (f *foreignFuture) Subscribe(onFulfilled func(values ...interface{}), onRejected func(err error)) {
+	go func() {
+		<-f.done
+		if f.err != nil {
+			onRejected(f.err)
+		} else {
+			onFulfilled(f.values...)
+		}
+	}()
+}
+
+func TestHandlerReturningThenableIsAdopted(t *testing.T) {
+	// Prepare
+	future := newForeignFuture()
+	// Test
+	p := promise.Resolve(1).Then(func(value int) (*foreignFuture, error) {
+		return future, nil
+	})
+	future.complete(nil, "foreign", 2)
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "foreign", 2)
+}
+
+func TestThenableRejectionIsAdopted(t *testing.T) {
+	// Prepare
+	failure := errors.New("foreign failure")
+	// Test
+	p := promise.Resolve(1).Then(func(value int) (interface{}, error) {
+		return newForeignFuture().complete(failure), nil
+	})
+	// Verify
+	promisetest.AssertRejected(t, p, settleTimeout, failure)
+}
+
+func TestAdoptThenablesInCombinators(t *testing.T) {
+	// Prepare
+	failure := errors.New("foreign failure")
+	// Test
+	all := promise.All(newForeignFuture().complete(nil, 1), promise.Resolve(2))
+	race := promise.Race(newForeignFuture(), newForeignFuture().complete(nil, "winner"))
+	raceIndexed := promise.RaceIndexed(newForeignFuture(), newForeignFuture().complete(failure))
+	anyOf := promise.Any(newForeignFuture().complete(failure), newForeignFuture().complete(nil, "any"))
+	some := promise.Some(2, newForeignFuture().complete(nil, 1), newForeignFuture().complete(nil, 2))
+	mapped := promise.Map([]int{3, 4}, func(value int) promise.Thenable {
+		return newForeignFuture().complete(nil, value)
+	})
+	// Verify
+	promisetest.AssertFulfilled(t, all, settleTimeout, []interface{}{1, 2})
+	promisetest.AssertFulfilled(t, race, settleTimeout, "winner")
+	promisetest.AssertRejected(t, raceIndexed, settleTimeout, "promise 1: foreign failure")
+	promisetest.AssertFulfilled(t, anyOf, settleTimeout, "any")
+	if promisetest.AssertFulfilled(t, some, settleTimeout) {
+		assert.ElementsMatch(t, []interface{}{1, 2}, some.Results()[0])
+	}
+	promisetest.AssertFulfilled(t, mapped, settleTimeout, []interface{}{3, 4})
+}
+
+func TestPromiseIsThenable(t *testing.T) {
+	// Prepare
+	p := promise.Resolve("resolved")
+	subscribed := promise.NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		// Test
+		p.Subscribe(resolve, nil)
+	})
+	// Verify
+	assert.Equal(t, p, promise.Adopt(p))
+	promisetest.AssertFulfilled(t, subscribed, settleTimeout, "resolved")
+}
+
+func TestResolveAdoptsDeeply(t *testing.T) {
+	// Prepare
+	var self promise.Promise
+	ready := make(chan struct{})
+	// Test
+	nested := promise.Resolve(promise.Resolve(promise.Resolve(1)))
+	foreign := promise.Resolve(newForeignFuture().complete(nil, promise.Resolve(newForeignFuture().complete(nil, 2))))
+	self = promise.NewPromise(func(resolve func(...interface{}), reject func(error)) {
+		<-ready
+		resolve(self)
+	})
+	close(ready)
+	// Verify
+	promisetest.AssertFulfilled(t, nested, settleTimeout, 1)
+	promisetest.AssertFulfilled(t, foreign, settleTimeout, 2)
+	promisetest.AssertRejected(t, self, settleTimeout, reflect.TypeOf(&promise.CycleError{}))
+}
+
+func TestRaceResolvesWithWinnersValue(t *testing.T) {
+	// Test
+	p := promise.Race(pending(), promise.Resolve("winner"))
+	// Verify
+	promisetest.AssertFulfilled(t, p, settleTimeout, "winner")
+}
@@ -1,6 +1,11 @@
 package promise
 
-import "reflect"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+)
 
 type typedError interface {
 	error
@@ -21,6 +26,87 @@ func (e protoTypedError) IsTypeOf(typ reflect.Type) bool {
 		(e.error != nil && reflect.TypeOf(e.error).Implements(typedErrorType) && e.error.(typedError).IsTypeOf(typ))
 }
 
+func (e protoTypedError) Unwrap() error {
+	return e.error
+}
+
 func Typed(err error) typedError {
 	return protoTypedError{err}
-}
\ No newline at end of file
+}
+
+// Extracts the value of type errorType out of the error chain of err. Typed errors reporting themselves as errorType are
+// taken as is, everything else goes through errors.As.
+func matchError(err error, errorType reflect.Type) (reflect.Value, bool) {
+	if err == nil { return reflect.Value{}, false }
+
+	if typedError, isTyped := err.(typedError); isTyped && typedError.IsTypeOf(errorType) &&
+		reflect.TypeOf(err).AssignableTo(errorType) {
+		return reflect.ValueOf(err), true
+	}
+
+	target := reflect.New(errorType)
+	if errors.As(err, target.Interface()) {
+		return target.Elem(), true
+	}
+	return reflect.Value{}, false
+}
+
+// OperationalError marks an expected failure that the caller can recover from, like a record that was not found or a
+// request that timed out.
+type OperationalError struct {
+	protoTypedError
+}
+
+func (e *OperationalError) IsTypeOf(typ reflect.Type) bool {
+	return reflect.TypeOf(e) == typ || e.protoTypedError.IsTypeOf(typ)
+}
+
+// ProgrammerError marks a bug in the code using the promises: a panicking handler, a handler with a signature that does
+// not fit the values it is called with and the like. Catch handlers and Then rejectors taking a plain error let
+// programmer errors pass through, they have to be caught explicitly with a *ProgrammerError handler or with CatchIf.
+type ProgrammerError struct {
+	protoTypedError
+	stack []byte
+}
+
+func (e *ProgrammerError) IsTypeOf(typ reflect.Type) bool {
+	return reflect.TypeOf(e) == typ || e.protoTypedError.IsTypeOf(typ)
+}
+
+// Stack returns the stack trace captured when a panic was turned into the error, if any.
+func (e *ProgrammerError) Stack() []byte {
+	return e.stack
+}
+
+func Operational(err error) error {
+	if err == nil { return nil }
+	return &OperationalError{protoTypedError{err}}
+}
+
+func Programmer(err error) error {
+	if err == nil { return nil }
+	return &ProgrammerError{protoTypedError: protoTypedError{err}}
+}
+
+func newPanicError(recovered interface{}) *ProgrammerError {
+	// Like the ones go-bird panics with when a handler is registered with a signature it does not support.
+	if programmerError, isProgrammerError := recovered.(*ProgrammerError); isProgrammerError {
+		return programmerError
+	}
+
+	err, isError := recovered.(error)
+	if !isError {
+		err = fmt.Errorf("%v", recovered)
+	}
+	return &ProgrammerError{protoTypedError{fmt.Errorf("panic: %w", err)}, debug.Stack()}
+}
+
+func IsOperationalError(err error) bool {
+	var operationalError *OperationalError
+	return errors.As(err, &operationalError)
+}
+
+func IsProgrammerError(err error) bool {
+	var programmerError *ProgrammerError
+	return errors.As(err, &programmerError)
+}